@@ -2,7 +2,7 @@ package path
 
 import (
 	"bytes"
-	"fmt"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 )
@@ -18,6 +18,9 @@ type Expression interface {
 // QueryExpression represents a query full of expressions
 type QueryExpression struct {
 	Expressions []Expression
+	// Comments holds all the comments found in the query, in the order
+	// they were found.
+	Comments []*Comment
 }
 
 // Pos returns the first position of the query expression.
@@ -51,6 +54,11 @@ func (e *QueryExpression) String() string {
 type ExpressionStatement struct {
 	Token      Token
 	Expression Expression
+	// Doc holds the comments found before, or within the statement.
+	Doc []*Comment
+	// Comment holds the comment found trailing the statement on the same
+	// line.
+	Comment *Comment
 }
 
 // Pos returns the first position of the expression statement.
@@ -60,6 +68,9 @@ func (es *ExpressionStatement) Pos() Position {
 
 // End returns the last position of the expression statement.
 func (es *ExpressionStatement) End() Position {
+	if es.Expression == nil {
+		return es.Token.End()
+	}
 	return es.Expression.End()
 }
 
@@ -92,6 +103,9 @@ func (ie *InfixExpression) Pos() Position {
 
 // End returns the last position of the identifier.
 func (ie *InfixExpression) End() Position {
+	if ie.Right == nil {
+		return ie.Token.End()
+	}
 	return ie.Right.End()
 }
 
@@ -121,6 +135,9 @@ func (ie *AccessorExpression) Pos() Position {
 
 // End returns the last position of the identifier.
 func (ie *AccessorExpression) End() Position {
+	if ie.Right == nil {
+		return ie.Token.End()
+	}
 	return ie.Right.End()
 }
 
@@ -146,14 +163,14 @@ func (i *Identifier) Pos() Position {
 
 // End returns the last position of the identifier.
 func (i *Identifier) End() Position {
-	length := utf8.RuneCountInString(i.Token.Literal)
-	return Position{
-		Line:   i.Token.Pos.Line,
-		Column: i.Token.Pos.Column + length,
+	raw := i.Token.Raw
+	if raw == "" {
+		raw = QuoteIdent(i.Token.Literal)
 	}
+	return endOf(i.Token.Pos, raw)
 }
 
-func (i *Identifier) String() string { return i.Token.Literal }
+func (i *Identifier) String() string { return QuoteIdent(i.Token.Literal) }
 
 // String represents an string for a given AST block
 type String struct {
@@ -167,14 +184,14 @@ func (i *String) Pos() Position {
 
 // End returns the last position of the string.
 func (i *String) End() Position {
-	length := utf8.RuneCountInString(i.Token.Literal)
-	return Position{
-		Line:   i.Token.Pos.Line,
-		Column: i.Token.Pos.Column + length,
+	raw := i.Token.Raw
+	if raw == "" {
+		raw = strconv.Quote(i.Token.Literal)
 	}
+	return endOf(i.Token.Pos, raw)
 }
 
-func (i *String) String() string { return fmt.Sprintf("%q", i.Token.Literal) }
+func (i *String) String() string { return strconv.Quote(i.Token.Literal) }
 
 // IndexExpression represents an expression that is associated with an operator.
 type IndexExpression struct {
@@ -190,6 +207,9 @@ func (ie *IndexExpression) Pos() Position {
 
 // End returns the last position of the identifier.
 func (ie *IndexExpression) End() Position {
+	if ie.Index == nil {
+		return ie.Token.End()
+	}
 	return ie.Index.End()
 }
 
@@ -219,6 +239,9 @@ func (ie *AccessExpression) Pos() Position {
 
 // End returns the last position of the identifier.
 func (ie *AccessExpression) End() Position {
+	if ie.Index == nil {
+		return ie.Token.End()
+	}
 	return ie.Index.End()
 }
 
@@ -247,7 +270,10 @@ func (i *DescentExpression) Pos() Position {
 
 // End returns the last position of the descent expression.
 func (i *DescentExpression) End() Position {
-	return i.Right.Pos()
+	if i.Right == nil {
+		return i.Token.End()
+	}
+	return i.Right.End()
 }
 
 func (i *DescentExpression) String() string {
@@ -277,3 +303,51 @@ func (i *Empty) End() Position {
 }
 
 func (i *Empty) String() string { return "()" }
+
+// Comment represents a comment found within a query.
+type Comment struct {
+	Token Token
+}
+
+// Pos returns the first position of the comment.
+func (c *Comment) Pos() Position {
+	return c.Token.Pos
+}
+
+// End returns the last position of the comment.
+func (c *Comment) End() Position {
+	return endOf(c.Token.Pos, c.Token.Literal)
+}
+
+// Text returns the text of the comment, without the comment markers.
+func (c *Comment) Text() string {
+	text := c.Token.Literal
+	switch {
+	case strings.HasPrefix(text, "#"):
+		text = text[1:]
+	case strings.HasPrefix(text, "//"):
+		text = text[2:]
+	case strings.HasPrefix(text, "/*"):
+		text = strings.TrimSuffix(text[2:], "*/")
+	}
+	return strings.TrimSpace(text)
+}
+
+func (c *Comment) String() string { return c.Token.Literal }
+
+// endOf returns the position after the text, which starts at the given
+// position. The text can span multiple lines.
+func endOf(pos Position, text string) Position {
+	end := Position{
+		Offset: pos.Offset + len(text),
+		Line:   pos.Line,
+		Column: pos.Column,
+	}
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		end.Line += strings.Count(text, "\n")
+		end.Column = 1
+		text = text[i+1:]
+	}
+	end.Column += utf8.RuneCountInString(text)
+	return end
+}
@@ -0,0 +1,73 @@
+package path_test
+
+import (
+	"testing"
+
+	"github.com/spoke-d/path"
+	"github.com/spoke-d/path/set"
+)
+
+var benchmarks = []struct {
+	name  string
+	query string
+}{
+	{name: "accessor", query: `company.person.name`},
+	{name: "filter", query: `company.person.(name == "fred")`},
+	{name: "descent", query: `company..`},
+	{name: "statements", query: `company.person.name; company.address; company["person"]`},
+}
+
+func benchmarkScope() path.Scope {
+	return set.MakeSet(map[string]interface{}{
+		"company": map[string]interface{}{
+			"person": map[string]interface{}{
+				"name": "fred",
+			},
+			"address": map[string]interface{}{
+				"street": "1 main street",
+				"city":   "london",
+			},
+		},
+	})
+}
+
+func BenchmarkRun(b *testing.B) {
+	for _, bench := range benchmarks {
+		b.Run(bench.name, func(b *testing.B) {
+			scope := benchmarkScope()
+			query, err := path.Parse(bench.query)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := query.Run(scope); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCompiledRun(b *testing.B) {
+	for _, bench := range benchmarks {
+		b.Run(bench.name, func(b *testing.B) {
+			scope := benchmarkScope()
+			query, err := path.Parse(bench.query)
+			if err != nil {
+				b.Fatal(err)
+			}
+			program := query.Compile()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := program.Run(scope); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package path
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Builder allows the construction of a query programmatically, without the
+// need to format a query string. The expressions built are the same as the
+// ones the parser would create for the equivalent query.
+//
+//	path.Ident("company").Field("person").Where(
+//		path.Eq(path.Ident("name"), path.Str("fred")),
+//	)
+//
+// Any error found whilst building is kept and returned when the expression is
+// requested.
+type Builder struct {
+	exp Expression
+	err error
+}
+
+// Ident creates a builder for an identifier.
+func Ident(name string) Builder {
+	if name == "" {
+		return errorBuilder(errors.Errorf("empty identifier"))
+	}
+	return Builder{
+		exp: &Identifier{
+			Token: MakeToken(IDENT, name),
+		},
+	}
+}
+
+// Str creates a builder for a string.
+func Str(value string) Builder {
+	return Builder{
+		exp: &String{
+			Token: MakeToken(STRING, value),
+		},
+	}
+}
+
+// Key creates a builder for accessing a key of the current scope, which is
+// the same as ["key"].
+func Key(key string) Builder {
+	return Builder{
+		exp: &AccessExpression{
+			Token: MakeToken(LBRACKET, "["),
+			Index: Str(key).exp,
+		},
+	}
+}
+
+// Field accesses the identifier with the given name, which is the same as
+// b.name
+func (b Builder) Field(name string) Builder {
+	return b.accessor(Ident(name))
+}
+
+// Key accesses the given key, which is the same as b["key"]
+func (b Builder) Key(key string) Builder {
+	return b.Index(Str(key))
+}
+
+// Index accesses the value of the index, which is the same as b[index]
+func (b Builder) Index(index Builder) Builder {
+	if err := firstError(b, index); err != nil {
+		return errorBuilder(err)
+	}
+	return Builder{
+		exp: &IndexExpression{
+			Token: MakeToken(LBRACKET, "["),
+			Left:  b.exp,
+			Index: index.exp,
+		},
+	}
+}
+
+// Where evaluates the predicate against the current expression, which is the
+// same as b.(predicate)
+func (b Builder) Where(predicate Builder) Builder {
+	return b.accessor(predicate)
+}
+
+// Descend accesses all the values of the current expression, which is the same
+// as b..
+func (b Builder) Descend() Builder {
+	if b.err != nil {
+		return b
+	}
+	return Builder{
+		exp: &AccessorExpression{
+			Token: MakeToken(PERIOD, "."),
+			Left:  b.exp,
+			Right: &DescentExpression{
+				Token: MakeToken(PERIOD, "."),
+			},
+		},
+	}
+}
+
+func (b Builder) accessor(right Builder) Builder {
+	if err := firstError(b, right); err != nil {
+		return errorBuilder(err)
+	}
+	return Builder{
+		exp: &AccessorExpression{
+			Token: MakeToken(PERIOD, "."),
+			Left:  b.exp,
+			Right: right.exp,
+		},
+	}
+}
+
+// Eq creates an equality comparison, left == right
+func Eq(left, right Builder) Builder { return infix(EQ, left, right) }
+
+// Neq creates an inequality comparison, left != right
+func Neq(left, right Builder) Builder { return infix(NEQ, left, right) }
+
+// Lt creates a less than comparison, left < right
+func Lt(left, right Builder) Builder { return infix(LT, left, right) }
+
+// Le creates a less than or equal comparison, left <= right
+func Le(left, right Builder) Builder { return infix(LE, left, right) }
+
+// Gt creates a greater than comparison, left > right
+func Gt(left, right Builder) Builder { return infix(GT, left, right) }
+
+// Ge creates a greater than or equal comparison, left >= right
+func Ge(left, right Builder) Builder { return infix(GE, left, right) }
+
+// And creates a conditional and, left && right
+func And(left, right Builder) Builder { return infix(CONDAND, left, right) }
+
+// Or creates a conditional or, left || right
+func Or(left, right Builder) Builder { return infix(CONDOR, left, right) }
+
+func infix(tokenType TokenType, left, right Builder) Builder {
+	if err := firstError(left, right); err != nil {
+		return errorBuilder(err)
+	}
+	operator := tokenType.String()
+	return Builder{
+		exp: &InfixExpression{
+			Token:    MakeToken(tokenType, operator),
+			Operator: operator,
+			Left:     left.exp,
+			Right:    right.exp,
+		},
+	}
+}
+
+// Expression returns the expression that has been built, or the first error
+// found whilst building.
+func (b Builder) Expression() (Expression, error) {
+	if b.err != nil {
+		return nil, errors.WithStack(b.err)
+	}
+	if b.exp == nil {
+		return nil, errors.Errorf("missing expression")
+	}
+	if err := validate(b.exp); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return b.exp, nil
+}
+
+// Path returns a path for running the expression that has been built.
+func (b Builder) Path() (Path, error) {
+	return Build(b)
+}
+
+// String returns the canonical source of the expression, or an empty string
+// if there was an error whilst building.
+func (b Builder) String() string {
+	if b.err != nil || b.exp == nil {
+		return ""
+	}
+	return Format(b.exp)
+}
+
+// Build creates a path from one or more builders, with each builder being a
+// statement of the query.
+func Build(statements ...Builder) (Path, error) {
+	var ast QueryExpression
+	for _, b := range statements {
+		exp, err := b.Expression()
+		if err != nil {
+			return Path{}, errors.WithStack(err)
+		}
+		ast.Expressions = append(ast.Expressions, &ExpressionStatement{
+			Token:      firstToken(exp),
+			Expression: exp,
+		})
+	}
+	return FromAST(&ast)
+}
+
+// firstToken returns the token the parser would have started the expression
+// with.
+func firstToken(e Expression) Token {
+	switch node := e.(type) {
+	case *InfixExpression:
+		return firstToken(node.Left)
+	case *AccessorExpression:
+		return firstToken(node.Left)
+	case *IndexExpression:
+		return firstToken(node.Left)
+	case *Identifier:
+		return node.Token
+	case *String:
+		return node.Token
+	}
+	return MakeToken(UNKNOWN, "")
+}
+
+func errorBuilder(err error) Builder {
+	return Builder{
+		err: err,
+	}
+}
+
+func firstError(builders ...Builder) error {
+	for _, b := range builders {
+		if b.err != nil {
+			return b.err
+		}
+		if b.exp == nil {
+			return errors.Errorf("missing expression")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package path
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestBuilder(t *testing.T) {
+	tests := []struct {
+		builder  Builder
+		expected string
+	}{
+		{
+			builder:  Ident("company").Field("person").Where(Eq(Ident("name"), Str("fred"))),
+			expected: `company.person.(name == "fred")`,
+		},
+		{
+			builder:  Ident("aaa").Key("bbb").Index(Ident("ccc")),
+			expected: `aaa["bbb"][ccc]`,
+		},
+		{
+			builder:  Ident("aaa").Where(Or(Key("bbb"), And(Ident("ccc"), Ident("ddd")))),
+			expected: `aaa.(["bbb"] || ccc && ddd)`,
+		},
+		{
+			builder:  Ident("aaa").Where(And(Or(Ident("bbb"), Ident("ccc")), Ident("ddd"))),
+			expected: `aaa.((bbb || ccc) && ddd)`,
+		},
+		{
+			builder:  Ident("aaa").Descend(),
+			expected: `aaa..`,
+		},
+		{
+			builder:  Ident("app.kubernetes.io/name"),
+			expected: `app\.kubernetes\.io\/name`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			if got := test.builder.String(); got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+
+			built, err := test.builder.Path()
+			if err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := Parse(test.expected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected, got := dumpExpression(parsed.AST()), dumpExpression(built.AST()); got != expected {
+				t.Errorf("expected %s, got %s", expected, got)
+			}
+		})
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	tests := []Builder{
+		Ident(""),
+		Ident("aaa").Field(""),
+		Eq(Ident("aaa"), Builder{}),
+		Ident("aaa").Where(And(Ident(""), Ident("bbb"))),
+	}
+	for _, test := range tests {
+		if _, err := test.Expression(); err == nil {
+			t.Errorf("expected error")
+		}
+		if _, err := Build(test); err == nil {
+			t.Errorf("expected error")
+		}
+	}
+}
+
+func TestBuilderRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	name := MakeStringScope("fred")
+
+	person := NewMockScope(ctrl)
+	person.EXPECT().GetIdentValue("name").Return(name, nil)
+	person.EXPECT().GetIdentValue("fred").Return(nil, ErrNotFound)
+
+	company := NewMockScope(ctrl)
+	company.EXPECT().GetIdentValue("person").Return(person, nil)
+
+	root := NewMockScope(ctrl)
+	root.EXPECT().GetIdentValue("company").Return(company, nil)
+
+	query, err := Build(Ident("company").Field("person").Where(Eq(Ident("name"), Str("fred"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := query.Run(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(*Scopes).scopes[0]; got != name {
+		t.Errorf("expected %v, got %v", name, got)
+	}
+}
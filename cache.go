@@ -0,0 +1,137 @@
+package path
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// CacheStats holds the statistics of a cache.
+type CacheStats struct {
+	// Hits is the number of queries found in the cache.
+	Hits uint64
+	// Misses is the number of queries that had to be parsed.
+	Misses uint64
+	// Evictions is the number of queries removed to make space for new ones.
+	Evictions uint64
+	// Size is the current number of queries held in the cache.
+	Size int
+	// Capacity is the maximum number of queries held in the cache.
+	Capacity int
+}
+
+// Cache holds a limited number of parsed and compiled queries, keyed by their
+// source. When the cache is full, the least recently used query is evicted.
+//
+// The cache is safe to use concurrently from multiple goroutines, along with
+// the paths and programs it returns, as neither hold any mutable state.
+type Cache struct {
+	mutex     sync.Mutex
+	capacity  int
+	items     map[string]*list.Element
+	order     *list.List
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+type cacheEntry struct {
+	src     string
+	path    Path
+	program *Program
+}
+
+// NewCache creates a new cache that holds up to the capacity of queries. A
+// capacity of zero or less means the cache is unbounded.
+func NewCache(capacity int) *Cache {
+	return &Cache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the path for a given query source, parsing the query if it
+// isn't already in the cache. Queries that fail to parse are not cached.
+func (c *Cache) Get(src string) (Path, error) {
+	entry, err := c.get(src)
+	if err != nil {
+		return Path{}, errors.WithStack(err)
+	}
+	return entry.path, nil
+}
+
+// Program returns the compiled program for a given query source, parsing and
+// compiling the query if it isn't already in the cache.
+func (c *Cache) Program(src string) (*Program, error) {
+	entry, err := c.get(src)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return entry.program, nil
+}
+
+func (c *Cache) get(src string) (*cacheEntry, error) {
+	c.mutex.Lock()
+	if elem, ok := c.items[src]; ok {
+		c.order.MoveToFront(elem)
+		c.hits++
+		c.mutex.Unlock()
+		return elem.Value.(*cacheEntry), nil
+	}
+	c.misses++
+	c.mutex.Unlock()
+
+	// Parse outside of the lock, so that a slow parse doesn't block others
+	// from using the cache.
+	path, err := Parse(src)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	entry := &cacheEntry{
+		src:     src,
+		path:    path,
+		program: path.Compile(),
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// Another goroutine may have added the same query in the mean time.
+	if elem, ok := c.items[src]; ok {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*cacheEntry), nil
+	}
+	c.items[src] = c.order.PushFront(entry)
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).src)
+		c.evictions++
+	}
+	return entry, nil
+}
+
+// Stats returns the current statistics of the cache.
+func (c *Cache) Stats() CacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return CacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Size:      c.order.Len(),
+		Capacity:  c.capacity,
+	}
+}
+
+// Purge removes all the queries from the cache. The statistics are kept.
+func (c *Cache) Purge() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+}
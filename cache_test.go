@@ -0,0 +1,130 @@
+package path
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestCache(t *testing.T) {
+	cache := NewCache(2)
+
+	first, err := cache.Get("aaa.bbb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := cache.Get("aaa.bbb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.AST() != again.AST() {
+		t.Errorf("expected the cached path")
+	}
+
+	if _, err := cache.Get("aaa.ccc"); err != nil {
+		t.Fatal(err)
+	}
+	// Use aaa.bbb, so that aaa.ccc is evicted next.
+	if _, err := cache.Get("aaa.bbb"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Program("aaa.ddd"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Get("aaa.bbb"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := CacheStats{
+		Hits:      3,
+		Misses:    3,
+		Evictions: 1,
+		Size:      2,
+		Capacity:  2,
+	}
+	if got := cache.Stats(); got != expected {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestCacheParseError(t *testing.T) {
+	cache := NewCache(2)
+
+	if _, err := cache.Get("aaa.(bbb"); err == nil {
+		t.Fatal("expected error")
+	}
+	if size := cache.Stats().Size; size != 0 {
+		t.Errorf("expected no cached queries, got %d", size)
+	}
+}
+
+func TestCacheConcurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	root := newSuccessScope(ctrl)
+	cache := NewCache(4)
+
+	queries := []string{
+		`aaa.bbb`,
+		`aaa.bbb.ccc`,
+		`aaa.(bbb || ccc)`,
+		`aaa.(xxx || yyy)`,
+		`aaa.(bbb == "bbb")`,
+		`aaa...ddd.eee`,
+	}
+
+	expected := make(map[string]Scope)
+	for _, query := range queries {
+		path, err := Parse(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected[query], err = path.Run(root); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				query := queries[(i+j)%len(queries)]
+				path, err := cache.Get(query)
+				if err != nil {
+					errs <- err
+					return
+				}
+				got, err := path.Run(root)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !reflect.DeepEqual(expected[query], got) {
+					errs <- fmt.Errorf("%s: expected %v, got %v", query, expected[query], got)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits+stats.Misses != 1600 {
+		t.Errorf("expected 1600 lookups, got %d", stats.Hits+stats.Misses)
+	}
+	if stats.Size > 4 {
+		t.Errorf("expected at most 4 queries, got %d", stats.Size)
+	}
+}
@@ -0,0 +1,51 @@
+// Command path-lsp is a language server for path queries, which speaks the
+// Language Server Protocol over the standard input and output.
+//
+// Usage:
+//
+//	path-lsp [flags]
+//
+// With a JSON Schema, queries are checked against the schema and identifiers
+// are completed from it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/spoke-d/path/lsp"
+	"github.com/spoke-d/path/schema"
+)
+
+var schemaFile = flag.String("schema", "", "JSON Schema of the values queries are run against")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: path-lsp [flags]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	log.SetOutput(os.Stderr)
+	log.SetPrefix("path-lsp: ")
+
+	var options []lsp.Option
+	if *schemaFile != "" {
+		b, err := ioutil.ReadFile(*schemaFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s, err := schema.FromJSONSchema(b)
+		if err != nil {
+			log.Fatalf("%s: %v", *schemaFile, err)
+		}
+		options = append(options, lsp.WithSchema(s))
+	}
+
+	if err := lsp.NewServer(options...).Serve(os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// The formats documents can be read in.
+const (
+	inputJSON = "json"
+	inputYAML = "yaml"
+)
+
+// documentReader reads the documents of an input one at a time. Returns
+// io.EOF once there are no more documents.
+type documentReader interface {
+	next() (interface{}, error)
+}
+
+func newDocumentReader(format string, r io.Reader) documentReader {
+	if format == inputYAML {
+		return yamlDocuments{
+			decoder: yaml.NewDecoder(r),
+		}
+	}
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	return jsonDocuments{
+		decoder: decoder,
+	}
+}
+
+type jsonDocuments struct {
+	decoder *json.Decoder
+}
+
+func (d jsonDocuments) next() (interface{}, error) {
+	var doc interface{}
+	if err := d.decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// yamlDocuments reads YAML documents, converting them into the types that
+// encoding/json decodes into, so that they're queried and printed in the
+// same way as JSON documents.
+type yamlDocuments struct {
+	decoder *yaml.Decoder
+}
+
+func (d yamlDocuments) next() (interface{}, error) {
+	var doc interface{}
+	if err := d.decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return fromYAML(doc)
+}
+
+// fromYAML converts a decoded YAML value. The keys of a mapping become
+// strings and timestamps become RFC 3339 strings, as neither has a JSON
+// equivalent. Integers too large for an int are kept as a json.Number.
+func fromYAML(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(t))
+		for key, value := range t {
+			value, err := fromYAML(value)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			res[key] = value
+		}
+		return res, nil
+
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(t))
+		for key, value := range t {
+			name := yamlKey(key)
+			if _, ok := res[name]; ok {
+				return nil, errors.Errorf("duplicate key %q", name)
+			}
+			value, err := fromYAML(value)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			res[name] = value
+		}
+		return res, nil
+
+	case []interface{}:
+		res := make([]interface{}, len(t))
+		for i, value := range t {
+			value, err := fromYAML(value)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			res[i] = value
+		}
+		return res, nil
+
+	case uint64:
+		return json.Number(strconv.FormatUint(t, 10)), nil
+
+	case time.Time:
+		return t.Format(time.RFC3339Nano), nil
+
+	case nil, bool, string, int, float64:
+		return t, nil
+	}
+	return nil, errors.Errorf("unexpected YAML value %T", v)
+}
+
+func yamlKey(key interface{}) string {
+	switch t := key.(type) {
+	case string:
+		return t
+	case nil:
+		return "null"
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(key)
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// completer returns the candidates for completing the line at the byte offset
+// of the cursor, along with the byte offsets of the text that a candidate
+// replaces.
+type completer func(line string, offset int) (start, end int, candidates []string)
+
+// errNoRawMode is returned when a terminal can't be put into raw mode on the
+// platform, so lines are read without editing.
+var errNoRawMode = errors.New("history and tab completion aren't available on this platform")
+
+// lineReader reads lines typed by the user. When the input is a terminal the
+// line can be edited, with a history of the previous lines and completion.
+type lineReader struct {
+	in       *bufio.Reader
+	out      io.Writer
+	raw      func() (func(), error)
+	history  []string
+	complete completer
+	warned   bool
+
+	line   []rune
+	cursor int
+	prompt string
+}
+
+func newLineReader(in io.Reader, out io.Writer, raw func() (func(), error), complete completer) *lineReader {
+	return &lineReader{
+		in:       bufio.NewReader(in),
+		out:      out,
+		raw:      raw,
+		complete: complete,
+	}
+}
+
+// ReadLine reads a line, showing the prompt first. Returns io.EOF once the
+// input is closed, or the user ends the input on an empty line.
+func (l *lineReader) ReadLine(prompt string) (string, error) {
+	if l.raw != nil {
+		restore, err := l.raw()
+		if err == nil {
+			defer restore()
+			return l.edit(prompt)
+		}
+		if err == errNoRawMode && !l.warned {
+			l.warned = true
+			fmt.Fprintf(l.out, "note: %v\n", err)
+		}
+	}
+
+	fmt.Fprint(l.out, prompt)
+	line, err := l.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	l.addHistory(line)
+	return line, nil
+}
+
+// The keys handled whilst editing a line.
+const (
+	keyCtrlA     = 1
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyBackspace = 8
+	keyTab       = 9
+	keyLineFeed  = 10
+	keyCtrlK     = 11
+	keyEnter     = 13
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// edit reads a line a key at a time from a terminal in raw mode.
+func (l *lineReader) edit(prompt string) (string, error) {
+	l.prompt, l.line, l.cursor = prompt, nil, 0
+	// The position within the history, where the end is the new line.
+	index := len(l.history)
+	var pending string
+
+	l.redraw()
+	for {
+		r, _, err := l.in.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(l.line) > 0 {
+				break
+			}
+			return "", err
+		}
+
+		switch r {
+		case keyEnter, keyLineFeed:
+			fmt.Fprint(l.out, "\r\n")
+			line := string(l.line)
+			l.addHistory(line)
+			return line, nil
+
+		case keyCtrlC:
+			fmt.Fprint(l.out, "^C\r\n")
+			l.line, l.cursor = nil, 0
+			index = len(l.history)
+
+		case keyCtrlD:
+			if len(l.line) == 0 {
+				fmt.Fprint(l.out, "\r\n")
+				return "", io.EOF
+			}
+			l.deleteAt(l.cursor)
+
+		case keyBackspace, keyDelete:
+			if l.cursor > 0 {
+				l.cursor--
+				l.deleteAt(l.cursor)
+			}
+
+		case keyCtrlA:
+			l.cursor = 0
+
+		case keyCtrlE:
+			l.cursor = len(l.line)
+
+		case keyCtrlK:
+			l.line = l.line[:l.cursor]
+
+		case keyCtrlU:
+			l.line = append([]rune{}, l.line[l.cursor:]...)
+			l.cursor = 0
+
+		case keyTab:
+			l.completeLine()
+
+		case keyEscape:
+			switch l.escape() {
+			case 'A':
+				if index > 0 {
+					if index == len(l.history) {
+						pending = string(l.line)
+					}
+					index--
+					l.setLine(l.history[index])
+				}
+			case 'B':
+				if index < len(l.history) {
+					index++
+					if index == len(l.history) {
+						l.setLine(pending)
+					} else {
+						l.setLine(l.history[index])
+					}
+				}
+			case 'C':
+				if l.cursor < len(l.line) {
+					l.cursor++
+				}
+			case 'D':
+				if l.cursor > 0 {
+					l.cursor--
+				}
+			case 'H':
+				l.cursor = 0
+			case 'F':
+				l.cursor = len(l.line)
+			case '~':
+				l.deleteAt(l.cursor)
+			}
+
+		default:
+			if unicode.IsPrint(r) {
+				l.insert(string(r))
+			}
+		}
+		l.redraw()
+	}
+
+	fmt.Fprint(l.out, "\r\n")
+	line := string(l.line)
+	l.addHistory(line)
+	return line, nil
+}
+
+// escape reads the rest of an escape sequence, returning the final byte of
+// the sequences for the arrow, home, end and delete keys.
+func (l *lineReader) escape() byte {
+	b, err := l.in.ReadByte()
+	if err != nil || (b != '[' && b != 'O') {
+		return 0
+	}
+	var params []byte
+	for {
+		b, err := l.in.ReadByte()
+		if err != nil {
+			return 0
+		}
+		if b >= '0' && b <= '9' || b == ';' {
+			params = append(params, b)
+			continue
+		}
+		switch {
+		case b == '~' && string(params) == "3":
+			return '~'
+		case b == '~' && (string(params) == "1" || string(params) == "7"):
+			return 'H'
+		case b == '~' && (string(params) == "4" || string(params) == "8"):
+			return 'F'
+		case b == '~':
+			return 0
+		}
+		return b
+	}
+}
+
+func (l *lineReader) insert(s string) {
+	runes := []rune(s)
+	line := make([]rune, 0, len(l.line)+len(runes))
+	line = append(line, l.line[:l.cursor]...)
+	line = append(line, runes...)
+	line = append(line, l.line[l.cursor:]...)
+	l.line = line
+	l.cursor += len(runes)
+}
+
+func (l *lineReader) deleteAt(i int) {
+	if i < len(l.line) {
+		l.line = append(l.line[:i], l.line[i+1:]...)
+	}
+}
+
+func (l *lineReader) setLine(s string) {
+	l.line = []rune(s)
+	l.cursor = len(l.line)
+}
+
+// completeLine replaces the text at the cursor with the only candidate, or
+// the prefix common to all the candidates. If that doesn't change the line,
+// the candidates are listed.
+func (l *lineReader) completeLine() {
+	if l.complete == nil {
+		return
+	}
+	text := string(l.line)
+	offset := len(string(l.line[:l.cursor]))
+	start, end, candidates := l.complete(text, offset)
+	if len(candidates) == 0 {
+		return
+	}
+
+	insert := candidates[0]
+	for _, candidate := range candidates[1:] {
+		insert = commonPrefix(insert, candidate)
+	}
+	if len(candidates) > 1 && strings.HasPrefix(text[start:offset], insert) {
+		fmt.Fprintf(l.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+		return
+	}
+
+	l.line = []rune(text[:start] + insert + text[end:])
+	l.cursor = utf8.RuneCountInString(text[:start] + insert)
+}
+
+// redraw writes the prompt and the line, moving the cursor to its position.
+func (l *lineReader) redraw() {
+	fmt.Fprintf(l.out, "\r%s%s\x1b[K", l.prompt, string(l.line))
+	if n := len(l.line) - l.cursor; n > 0 {
+		fmt.Fprintf(l.out, "\x1b[%dD", n)
+	}
+}
+
+func (l *lineReader) addHistory(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if num := len(l.history); num > 0 && l.history[num-1] == line {
+		return
+	}
+	l.history = append(l.history, line)
+}
+
+func commonPrefix(a, b string) string {
+	x, y := []rune(a), []rune(b)
+	var i int
+	for i < len(x) && i < len(y) && x[i] == y[i] {
+		i++
+	}
+	return string(x[:i])
+}
@@ -0,0 +1,249 @@
+// Command path runs a query against JSON or YAML documents, printing the
+// results.
+//
+// Usage:
+//
+//	path [flags] query [file ...]
+//	path -i [-format yaml] file
+//
+// Without an explicit file, it reads from the standard input. The input can
+// hold any number of documents, each of which the query is run against. A
+// document is either an object or an array, whose identifiers are its
+// indexes. Documents are read as JSON, or as YAML with -format yaml, in which
+// case the keys of mappings are read as strings and timestamps as RFC 3339
+// strings. The results are always printed as JSON.
+//
+// With -i, the document of the file is loaded once and queries are read a
+// line at a time from the standard input, printing the results of each.
+//
+// The exit status is 0 if there are any results, 1 if there are none and 2 if
+// an error occurred. A value that isn't found is no result, rather than an
+// error, but any other error from running a query, such as values that can't
+// be compared or a limit being exceeded, is an error.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+	"github.com/spoke-d/path/set"
+)
+
+// The exit statuses of the command.
+const (
+	exitMatch   = 0
+	exitNoMatch = 1
+	exitError   = 2
+)
+
+// The formats results can be printed in.
+const (
+	formatJSON  = "json"
+	formatLines = "lines"
+	formatRaw   = "raw"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+type command struct {
+	query  path.Path
+	input  string
+	format string
+	stdout io.Writer
+	found  bool
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("path", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var (
+		input       = flags.String("format", inputJSON, "input format: json or yaml")
+		format      = flags.String("o", formatJSON, "output format: json, lines (compact json) or raw (strings unquoted)")
+		queryFile   = flags.String("f", "", "read the query from a file, instead of the first argument")
+		interactive = flags.Bool("i", false, "read queries interactively from the standard input")
+	)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: path [flags] query [file ...]\n")
+		fmt.Fprintf(stderr, "       path -i [-format yaml] file\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+	switch *input {
+	case inputJSON, inputYAML:
+	default:
+		fmt.Fprintf(stderr, "path: unknown input format %q\n", *input)
+		return exitError
+	}
+	switch *format {
+	case formatJSON, formatLines, formatRaw:
+	default:
+		fmt.Fprintf(stderr, "path: unknown output format %q\n", *format)
+		return exitError
+	}
+
+	args = flags.Args()
+	if *interactive {
+		if len(args) != 1 {
+			flags.Usage()
+			return exitError
+		}
+		scope, err := load(*input, args[0])
+		if err != nil {
+			fmt.Fprintf(stderr, "path: %s: %v\n", args[0], err)
+			return exitError
+		}
+		if err := newREPL(scope, stdin, stdout).Run(); err != nil {
+			fmt.Fprintf(stderr, "path: %v\n", err)
+			return exitError
+		}
+		return exitMatch
+	}
+
+	var src string
+	if *queryFile != "" {
+		b, err := ioutil.ReadFile(*queryFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "path: %v\n", err)
+			return exitError
+		}
+		src = string(b)
+	} else {
+		if len(args) == 0 {
+			flags.Usage()
+			return exitError
+		}
+		src, args = args[0], args[1:]
+	}
+
+	query, err := path.Parse(src)
+	if err != nil {
+		fmt.Fprintf(stderr, "path: %v\n", err)
+		return exitError
+	}
+
+	cmd := &command{
+		query:  query,
+		input:  *input,
+		format: *format,
+		stdout: stdout,
+	}
+	if len(args) == 0 {
+		if err := cmd.process(stdin); err != nil {
+			fmt.Fprintf(stderr, "path: <standard input>: %v\n", err)
+			return exitError
+		}
+	}
+	var failed bool
+	for _, filename := range args {
+		f, err := os.Open(filename)
+		if err == nil {
+			err = cmd.process(f)
+			f.Close()
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "path: %s: %v\n", filename, err)
+			failed = true
+		}
+	}
+
+	if failed {
+		return exitError
+	}
+	if !cmd.found {
+		return exitNoMatch
+	}
+	return exitMatch
+}
+
+// process runs the query against each document of the reader.
+func (c *command) process(r io.Reader) error {
+	documents := newDocumentReader(c.input, r)
+	for {
+		doc, err := documents.next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		scope, err := documentScope(doc)
+		if err != nil {
+			return err
+		}
+
+		iter := c.query.Iter(scope)
+		for {
+			result, ok := iter.Next()
+			if !ok {
+				break
+			}
+			value, err := set.Unlift(result)
+			if err != nil {
+				return err
+			}
+			if err := printValue(c.stdout, c.format, value); err != nil {
+				return err
+			}
+			c.found = true
+		}
+		// A value that isn't found is no match for the document, rather than
+		// an error.
+		if err := iter.Err(); err != nil && errors.Cause(err) != path.ErrNotFound {
+			return err
+		}
+	}
+}
+
+// load reads the first document of a file.
+func load(input, filename string) (path.Scope, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	doc, err := newDocumentReader(input, f).next()
+	if err != nil {
+		return nil, err
+	}
+	return documentScope(doc)
+}
+
+func documentScope(doc interface{}) (path.Scope, error) {
+	switch t := doc.(type) {
+	case map[string]interface{}:
+		return set.MakeSet(t), nil
+	case []interface{}:
+		return set.MakeList(t), nil
+	}
+	return nil, errors.Errorf("expected an object or array, got %T", doc)
+}
+
+func printValue(w io.Writer, format string, value interface{}) error {
+	if s, ok := value.(string); ok && format == formatRaw {
+		_, err := fmt.Fprintln(w, s)
+		return err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if format == formatJSON {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
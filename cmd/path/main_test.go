@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testInput = `{"users": [{"name": "fred", "age": 30, "admin": true}, {"name": "bob", "age": 9, "admin": false}], "version": "1"}
+{"version": "2"}`
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		status int
+		stdout string
+	}{
+		{name: "json", args: []string{"version"}, stdout: "\"1\"\n\"2\"\n"},
+		{name: "raw", args: []string{"-o", "raw", "version"}, stdout: "1\n2\n"},
+		{name: "lines", args: []string{"-o", "lines", `users.["0"]`}, stdout: `{"admin":true,"age":30,"name":"fred"}` + "\n"},
+		{name: "indented", args: []string{`users.["1"].(name == "bob")`}, stdout: "\"bob\"\n"},
+		{name: "number", args: []string{"-o", "lines", `users.["0"].(age > "10")`}, stdout: "30\n"},
+		{name: "number no match", args: []string{`users.["1"].(age > "10")`}, status: exitNoMatch},
+		{name: "bool", args: []string{"-o", "lines", `users.["1"].(admin == "false")`}, stdout: "false\n"},
+		{name: "missing", args: []string{"missing"}, status: exitNoMatch},
+		{name: "missing statement", args: []string{"version; missing"}, stdout: "\"1\"\n\"2\"\n"},
+		{name: "string no match", args: []string{`(version == "2")`}, stdout: "\"2\"\n"},
+		{name: "syntax error", args: []string{"users["}, status: exitError},
+		{name: "no query", status: exitError},
+		{name: "unknown format", args: []string{"-o", "yaml", "version"}, status: exitError},
+		{name: "unknown input format", args: []string{"-format", "toml", "version"}, status: exitError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := run(test.args, strings.NewReader(testInput), &stdout, &stderr)
+			if status != test.status {
+				t.Errorf("expected status %d, got %d: %s", test.status, status, stderr.String())
+			}
+			if stdout.String() != test.stdout {
+				t.Errorf("expected %q, got %q", test.stdout, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunInvalidInput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"version"}, strings.NewReader(`"a"`), &stdout, &stderr); status != exitError {
+		t.Errorf("expected status %d, got %d", exitError, status)
+	}
+	if !strings.Contains(stderr.String(), "expected an object or array") {
+		t.Errorf("unexpected error %q", stderr.String())
+	}
+}
+
+func TestRunArray(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"-o", "raw", `["1"].name`}, strings.NewReader(`[{"name": "fred"}, {"name": "bob"}]`), &stdout, &stderr)
+	if status != exitMatch {
+		t.Errorf("expected status %d, got %d: %s", exitMatch, status, stderr.String())
+	}
+	if expected := "bob\n"; stdout.String() != expected {
+		t.Errorf("expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func TestRunQueryError(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	status := run([]string{`users.["0"].(admin < "true")`}, strings.NewReader(testInput), &stdout, &stderr)
+	if status != exitError {
+		t.Errorf("expected status %d, got %d", exitError, status)
+	}
+	if !strings.Contains(stderr.String(), "unable to order") {
+		t.Errorf("unexpected error %q", stderr.String())
+	}
+}
+
+const testYAML = `users:
+  - name: fred
+    age: 30
+    admin: true
+    born: 1990-01-02
+  - name: bob
+    age: 9
+    admin: false
+    born: 2013-05-06
+version: "1"
+codes:
+  1: one
+---
+version: "2"
+`
+
+func TestRunYAML(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		status int
+		stdout string
+	}{
+		{name: "documents", args: []string{"-format", "yaml", "version"}, stdout: "\"1\"\n\"2\"\n"},
+		{name: "lines", args: []string{"-format", "yaml", "-o", "lines", `users.["0"]`}, stdout: `{"admin":true,"age":30,"born":"1990-01-02T00:00:00Z","name":"fred"}` + "\n"},
+		{name: "number", args: []string{"-format", "yaml", "-o", "raw", `users.["1"].(age < "10")`}, stdout: "9\n"},
+		{name: "bool", args: []string{"-format", "yaml", `users.["1"].(admin == "false")`}, stdout: "false\n"},
+		{name: "key", args: []string{"-format", "yaml", "-o", "raw", `codes.["1"]`}, stdout: "one\n"},
+		{name: "missing", args: []string{"-format", "yaml", "missing"}, status: exitNoMatch},
+		{name: "error", args: []string{"-format", "yaml", `users.["0"].(admin < "true")`}, status: exitError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := run(test.args, strings.NewReader(testYAML), &stdout, &stderr)
+			if status != test.status {
+				t.Errorf("expected status %d, got %d: %s", test.status, status, stderr.String())
+			}
+			if stdout.String() != test.stdout {
+				t.Errorf("expected %q, got %q", test.stdout, stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunInvalidYAML(t *testing.T) {
+	for _, input := range []string{"a: [", "1: a\n\"1\": b\n", "- a\n---\nscalar\n"} {
+		var stdout, stderr bytes.Buffer
+		if status := run([]string{"-format", "yaml", `["0"]`}, strings.NewReader(input), &stdout, &stderr); status != exitError {
+			t.Errorf("expected status %d for %q, got %d", exitError, input, status)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spoke-d/path"
+	"github.com/spoke-d/path/complete"
+	"github.com/spoke-d/path/set"
+)
+
+const replHelp = `Enter a query to run it against the document, or one of the commands:
+
+  :explain <query>  run the query, showing how each expression was evaluated
+  :ast <query>      show the syntax tree of the query
+  :format <query>   show the canonical form of the query
+  :help             show this help
+  :quit             exit
+
+Press tab to complete identifiers, and the up and down arrows to move through
+the history.
+`
+
+// repl reads queries a line at a time, running each against the scope until
+// the input is closed.
+type repl struct {
+	scope  path.Scope
+	reader *lineReader
+	out    io.Writer
+}
+
+func newREPL(scope path.Scope, in io.Reader, out io.Writer) *repl {
+	r := &repl{
+		scope: scope,
+		out:   out,
+	}
+	var raw func() (func(), error)
+	if f, ok := in.(*os.File); ok {
+		raw = func() (func(), error) {
+			return makeRaw(int(f.Fd()))
+		}
+	}
+	r.reader = newLineReader(in, out, raw, r.complete)
+	return r
+}
+
+// Run reads and runs queries until the input is closed or the user quits.
+func (r *repl) Run() error {
+	fmt.Fprintln(r.out, `Type ":help" for help.`)
+	for {
+		line, err := r.reader.ReadLine("path> ")
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, ":") {
+			r.query(line)
+			continue
+		}
+
+		name, src := splitCommand(line)
+		switch name {
+		case "quit", "q":
+			return nil
+		case "help":
+			fmt.Fprint(r.out, replHelp)
+		case "explain":
+			r.explain(src)
+		case "ast":
+			r.ast(src)
+		case "format":
+			r.format(src)
+		default:
+			fmt.Fprintf(r.out, "unknown command %q, type \":help\" for help\n", ":"+name)
+		}
+	}
+}
+
+func (r *repl) query(src string) {
+	query, err := path.Parse(src)
+	if err != nil {
+		fmt.Fprintln(r.out, err)
+		return
+	}
+
+	var num int
+	iter := query.Iter(r.scope)
+	for {
+		result, ok := iter.Next()
+		if !ok {
+			break
+		}
+		num++
+		value, err := set.Unlift(result)
+		if err != nil {
+			fmt.Fprintln(r.out, err)
+			return
+		}
+		if err := printValue(r.out, formatJSON, value); err != nil {
+			fmt.Fprintln(r.out, err)
+			return
+		}
+	}
+	if err := iter.Err(); err != nil {
+		fmt.Fprintln(r.out, err)
+	} else if num == 0 {
+		fmt.Fprintln(r.out, "no results")
+	}
+}
+
+func (r *repl) explain(src string) {
+	query, err := path.Parse(src)
+	if err != nil {
+		fmt.Fprintln(r.out, err)
+		return
+	}
+	trace, _ := query.Explain(r.scope)
+	fmt.Fprint(r.out, trace.String())
+}
+
+func (r *repl) ast(src string) {
+	query, err := path.Parse(src)
+	if err != nil {
+		fmt.Fprintln(r.out, err)
+		return
+	}
+	path.Walk(astPrinter{out: r.out}, query.AST())
+}
+
+func (r *repl) format(src string) {
+	query, err := path.Parse(src)
+	if err != nil {
+		fmt.Fprintln(r.out, err)
+		return
+	}
+	fmt.Fprintln(r.out, query.Format())
+}
+
+var commands = []string{":ast", ":explain", ":format", ":help", ":quit"}
+
+// complete returns the candidates for completing a command, or the query of
+// a line.
+func (r *repl) complete(line string, offset int) (int, int, []string) {
+	var start int
+	if strings.HasPrefix(line, ":") {
+		i := strings.IndexByte(line, ' ')
+		if i < 0 || offset <= i {
+			var candidates []string
+			for _, name := range commands {
+				if strings.HasPrefix(name, line[:offset]) {
+					candidates = append(candidates, name+" ")
+				}
+			}
+			end := len(line)
+			if i >= 0 {
+				end = i + 1
+			}
+			return 0, end, candidates
+		}
+		start = i + 1
+	}
+
+	res := complete.Complete(line[start:], offset-start, complete.FromScope(r.scope))
+	var candidates []string
+	for _, candidate := range res.Candidates {
+		if candidate.Kind == complete.KindField {
+			candidates = append(candidates, candidate.Insert)
+		}
+	}
+	sort.Strings(candidates)
+	return start + res.Start, start + res.End, candidates
+}
+
+// splitCommand splits a command line into the name of the command and the
+// rest of the line.
+func splitCommand(line string) (string, string) {
+	line = strings.TrimPrefix(line, ":")
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		return line[:i], strings.TrimSpace(line[i+1:])
+	}
+	return line, ""
+}
+
+// astPrinter writes the syntax tree of a query, with an expression per line
+// indented by its depth.
+type astPrinter struct {
+	out   io.Writer
+	depth int
+}
+
+func (p astPrinter) Visit(e path.Expression) path.Visitor {
+	if e == nil {
+		return nil
+	}
+
+	name := strings.TrimPrefix(fmt.Sprintf("%T", e), "*path.")
+	switch node := e.(type) {
+	case *path.Identifier:
+		name += " " + node.Token.Literal
+	case *path.String:
+		name += " " + strconv.Quote(node.Token.Literal)
+	case *path.InfixExpression:
+		name += " " + node.Operator
+	}
+	fmt.Fprintf(p.out, "%s%s\n", strings.Repeat("  ", p.depth), name)
+
+	return astPrinter{
+		out:   p.out,
+		depth: p.depth + 1,
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spoke-d/path/set"
+)
+
+func testREPL(input string) (*repl, *bytes.Buffer) {
+	var out bytes.Buffer
+	scope := set.MakeSet(map[string]interface{}{
+		"company": map[string]interface{}{
+			"person": map[string]interface{}{
+				"name":  "fred",
+				"email": "fred@example.com",
+			},
+			"address": "1 main street",
+		},
+		"config": map[string]interface{}{},
+	})
+	return newREPL(scope, strings.NewReader(input), &out), &out
+}
+
+func TestREPL(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "query", input: "company.person.name\n", expected: "\"fred\"\n"},
+		{name: "pretty", input: "company.person\n", expected: "{\n  \"email\": \"fred@example.com\",\n  \"name\": \"fred\"\n}\n"},
+		{name: "no results", input: "config..\n", expected: "no results\n"},
+		{name: "error", input: "missing\n", expected: "no ident value \"missing\" found in scope\n"},
+		{name: "format", input: ":format company .person\n", expected: "company.person\n"},
+		{name: "ast", input: ":ast company.(name == \"fred\")\n", expected: `QueryExpression
+  ExpressionStatement
+    AccessorExpression
+      Identifier company
+      InfixExpression ==
+        Identifier name
+        String "fred"
+`},
+		{name: "explain", input: ":explain config\n", expected: "ExpressionStatement config: 1 result"},
+		{name: "unknown", input: ":nope\n", expected: "unknown command \":nope\""},
+		{name: "quit", input: ":quit\ncompany.person.name\n", expected: "path> "},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, out := testREPL(test.input)
+			if err := r.Run(); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out.String(), test.expected) {
+				t.Errorf("expected %q in %q", test.expected, out.String())
+			}
+			if test.name == "quit" && strings.Contains(out.String(), "fred") {
+				t.Errorf("expected no queries to be run after quit, got %q", out.String())
+			}
+		})
+	}
+}
+
+func TestREPLComplete(t *testing.T) {
+	tests := []struct {
+		line       string
+		start, end int
+		expected   []string
+	}{
+		{line: "co", start: 0, end: 2, expected: []string{"company", "config"}},
+		{line: "company.person.", start: 15, end: 15, expected: []string{"email", "name"}},
+		{line: ":ex", start: 0, end: 3, expected: []string{":explain "}},
+		{line: ":explain company.a", start: 17, end: 18, expected: []string{"address"}},
+	}
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			r, _ := testREPL("")
+			start, end, candidates := r.complete(test.line, len(test.line))
+			if start != test.start || end != test.end {
+				t.Errorf("expected %d:%d, got %d:%d", test.start, test.end, start, end)
+			}
+			if !reflect.DeepEqual(candidates, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, candidates)
+			}
+		})
+	}
+}
+
+func TestLineReaderEdit(t *testing.T) {
+	complete := func(line string, offset int) (int, int, []string) {
+		return 0, offset, []string{"company", "config"}
+	}
+	raw := func() (func(), error) {
+		return func() {}, nil
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{name: "lines", input: "a\rb\r", expected: []string{"a", "b"}},
+		{name: "backspace", input: "ab\x7fc\r", expected: []string{"ac"}},
+		{name: "cursor", input: "ac\x1b[Db\r", expected: []string{"abc"}},
+		{name: "home and end", input: "b\x01a\x05c\r", expected: []string{"abc"}},
+		{name: "delete", input: "abc\x01\x1b[3~\r", expected: []string{"bc"}},
+		{name: "kill", input: "abc\x1b[D\x0b\r", expected: []string{"ab"}},
+		{name: "history", input: "a\rb\r\x1b[A\x1b[A\r", expected: []string{"a", "b", "a"}},
+		{name: "history back to new line", input: "a\rx\x1b[A\x1b[B\r", expected: []string{"a", "x"}},
+		{name: "interrupt", input: "abc\x03d\r", expected: []string{"d"}},
+		{name: "complete common prefix", input: "\t\r", expected: []string{"co"}},
+		{name: "complete listed", input: "co\tm\r", expected: []string{"com"}},
+		{name: "end of input", input: "a\r\x04", expected: []string{"a"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			reader := newLineReader(strings.NewReader(test.input), &out, raw, complete)
+			var lines []string
+			for {
+				line, err := reader.ReadLine("> ")
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				lines = append(lines, line)
+			}
+			if !reflect.DeepEqual(lines, test.expected) {
+				t.Errorf("expected %q, got %q", test.expected, lines)
+			}
+		})
+	}
+}
+
+func TestLineReaderNoRawMode(t *testing.T) {
+	raw := func() (func(), error) {
+		return nil, errNoRawMode
+	}
+
+	var out bytes.Buffer
+	reader := newLineReader(strings.NewReader("a\nb\n"), &out, raw, nil)
+	var lines []string
+	for {
+		line, err := reader.ReadLine("> ")
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	if expected := []string{"a", "b"}; !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected %q, got %q", expected, lines)
+	}
+	if got := strings.Count(out.String(), errNoRawMode.Error()); got != 1 {
+		t.Errorf("expected the lack of raw mode to be noted once, got %q", out.String())
+	}
+}
@@ -0,0 +1,12 @@
+//go:build linux
+// +build linux
+
+package main
+
+import "syscall"
+
+// The requests to get and set the attributes of a terminal.
+const (
+	ioctlGetTermios = syscall.TCGETS
+	ioctlSetTermios = syscall.TCSETS
+)
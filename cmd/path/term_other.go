@@ -0,0 +1,10 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package main
+
+// makeRaw isn't supported, so lines are read without editing, history or
+// completion.
+func makeRaw(fd int) (func(), error) {
+	return nil, errNoRawMode
+}
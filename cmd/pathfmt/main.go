@@ -0,0 +1,91 @@
+// Command pathfmt formats path queries into their canonical form.
+//
+// Usage:
+//
+//	pathfmt [flags] [path ...]
+//
+// Without an explicit path, it reads from the standard input.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/spoke-d/path"
+)
+
+var (
+	list  = flag.Bool("l", false, "list files whose formatting differs from pathfmt's")
+	write = flag.Bool("w", false, "write result to (source) file instead of stdout")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: pathfmt [flags] [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		if *write {
+			fatalf("cannot use -w with standard input")
+		}
+		src, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if err := process("<standard input>", src); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+
+	var failed bool
+	for _, filename := range flag.Args() {
+		src, err := ioutil.ReadFile(filename)
+		if err == nil {
+			err = process(filename, src)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(2)
+	}
+}
+
+func process(filename string, src []byte) error {
+	query, err := path.Parse(string(src))
+	if err != nil {
+		return err
+	}
+	res := []byte(query.Format() + "\n")
+
+	if *list {
+		if string(res) != string(src) {
+			fmt.Println(filename)
+		}
+		return nil
+	}
+	if *write {
+		if string(res) == string(src) {
+			return nil
+		}
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filename, res, info.Mode().Perm())
+	}
+	_, err = os.Stdout.Write(res)
+	return err
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "pathfmt: "+format+"\n", args...)
+	os.Exit(2)
+}
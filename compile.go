@@ -0,0 +1,192 @@
+package path
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Program is a compiled query, which can be run repeatedly without having to
+// walk the expressions of the query for every run.
+//
+// A Program holds no mutable state, so it can be shared and run concurrently
+// by multiple goroutines.
+type Program struct {
+	eval evalFunc
+}
+
+// singleScopes allows a Scopes holding a single scope to be allocated at
+// once.
+type singleScopes struct {
+	scopes Scopes
+	buf    [1]Scope
+}
+
+// evalFunc evaluates a compiled expression against a given scope.
+type evalFunc func(Scope) (Scope, error)
+
+// Compile the query into a tree of closures, resolving everything that can be
+// known before the query is run.
+// The result of running the program is the same as running the query.
+func (q Path) Compile() *Program {
+	var e Expression = q.ast
+	if q.ast == nil {
+		e = &QueryExpression{}
+	}
+	return &Program{
+		eval: compile(e),
+	}
+}
+
+// Run the program over a given scope.
+func (p *Program) Run(scope Scope) (Scope, error) {
+	result, err := p.eval(scope)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return result, nil
+}
+
+func compile(e Expression) evalFunc {
+	switch node := e.(type) {
+	case *QueryExpression:
+		exps := make([]evalFunc, len(node.Expressions))
+		for i, exp := range node.Expressions {
+			exps[i] = compile(exp)
+		}
+		if len(exps) == 1 {
+			// Most queries only have the one statement, so allocate the
+			// result in one go.
+			exp := exps[0]
+			return func(scope Scope) (Scope, error) {
+				result, err := exp(scope)
+				if err != nil {
+					return nil, err
+				}
+				res := &singleScopes{}
+				res.buf[0] = result
+				res.scopes.scopes = res.buf[:]
+				return &res.scopes, nil
+			}
+		}
+		return func(scope Scope) (Scope, error) {
+			scopes := make([]Scope, len(exps))
+			for i, exp := range exps {
+				result, err := exp(scope)
+				if err != nil {
+					return nil, err
+				}
+				scopes[i] = result
+			}
+			return NewScopes(scopes), nil
+		}
+
+	case *ExpressionStatement:
+		return compile(node.Expression)
+
+	case *Identifier:
+		name := node.Token.Literal
+		return func(scope Scope) (Scope, error) {
+			return scope.GetIdentValue(name)
+		}
+
+	case *String:
+		name := node.Token.Literal
+		var literal Scope = MakeStringScope(name)
+		return func(scope Scope) (Scope, error) {
+			if s, err := scope.GetIdentValue(name); err == nil && s != nil {
+				return s, nil
+			}
+			return literal, nil
+		}
+
+	case *AccessorExpression:
+		return compileAccessor(compile(node.Left), compile(node.Right))
+
+	case *IndexExpression:
+		return compileAccessor(compile(node.Left), compile(node.Index))
+
+	case *AccessExpression:
+		return compile(node.Index)
+
+	case *DescentExpression:
+		return func(scope Scope) (Scope, error) {
+			idents := scope.GetAllIdents()
+			scopes := make([]Scope, len(idents))
+			for i, ident := range idents {
+				result, err := scope.GetIdentValue(ident)
+				if err != nil {
+					return nil, err
+				}
+				scopes[i] = result
+			}
+			return NewScopes(scopes), nil
+		}
+
+	case *InfixExpression:
+		return compileInfix(node)
+	}
+
+	return func(Scope) (Scope, error) {
+		return nil, RuntimeErrorf("Syntax Error: Unexpected expression %T", e)
+	}
+}
+
+func compileAccessor(left, right evalFunc) evalFunc {
+	return func(scope Scope) (Scope, error) {
+		parent, err := left(scope)
+		if err != nil {
+			return nil, err
+		}
+		return right(parent)
+	}
+}
+
+func compileInfix(node *InfixExpression) evalFunc {
+	left, right := compile(node.Left), compile(node.Right)
+
+	switch node.Token.Type {
+	case CONDAND:
+		return func(scope Scope) (Scope, error) {
+			l, err := left(scope)
+			if err != nil {
+				return nil, err
+			}
+			r, err := right(scope)
+			if err != nil {
+				return nil, err
+			}
+			return NewScopes([]Scope{l, r}), nil
+		}
+
+	case CONDOR:
+		return func(scope Scope) (Scope, error) {
+			l, err := left(scope)
+			if err == nil {
+				return l, nil
+			} else if errors.Cause(err) != ErrNotFound {
+				return nil, err
+			}
+			return right(scope)
+		}
+	}
+
+	op, err := liftOperation(node.Token.Type)
+	if err != nil {
+		return func(Scope) (Scope, error) {
+			return nil, err
+		}
+	}
+	return func(scope Scope) (Scope, error) {
+		l, leftErr := left(scope)
+		if leftErr != nil && errors.Cause(leftErr) != ErrNotFound {
+			return nil, leftErr
+		}
+		r, err := right(scope)
+		if err != nil {
+			return nil, err
+		}
+		if leftErr != nil {
+			return nil, leftErr
+		}
+		return l.RunOperation(op, unlocated(r))
+	}
+}
@@ -0,0 +1,77 @@
+package path
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestCompile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	root := newSuccessScope(ctrl)
+
+	res, err := ioutil.ReadFile("./testfiles/success")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewBuffer(res))
+	for scanner.Scan() {
+		line := scanner.Text()
+		t.Run(line, func(t *testing.T) {
+			query, err := Parse(line)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected, err := query.Run(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := query.Compile().Run(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(expected, got) {
+				t.Errorf("expected %v, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	root := newSuccessScope(ctrl)
+
+	tests := []string{
+		`aaa.`,
+		`aaa.()`,
+		`aaa.(xxx && bbb)`,
+		`aaa.(xxx == "bbb")`,
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			query, err := Parse(test)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, expected := query.Run(root)
+			_, got := query.Compile().Run(root)
+			if expected == nil || got == nil {
+				t.Fatalf("expected errors, got %v and %v", expected, got)
+			}
+			if expected.Error() != got.Error() {
+				t.Errorf("expected %v, got %v", expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+// Package complete finds the completions of a partial query at a cursor, which
+// can be used to power editors and query boxes.
+package complete
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spoke-d/path"
+	"github.com/spoke-d/path/schema"
+)
+
+// Kind is the kind of a completion candidate.
+type Kind int
+
+// The kinds of completion candidates. The query language has no functions,
+// so only identifiers and operators are offered.
+const (
+	KindField Kind = iota
+	KindOperator
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindField:
+		return "field"
+	case KindOperator:
+		return "operator"
+	}
+	return "unknown"
+}
+
+// Candidate is a single completion.
+type Candidate struct {
+	// Label is the text shown for the candidate.
+	Label string
+	// Insert is the text that replaces the text between the start and end of
+	// the result.
+	Insert string
+	// Kind is the kind of the candidate.
+	Kind Kind
+	// Detail describes the candidate, such as the type of a field.
+	Detail string
+}
+
+// Result holds the candidates for completing a query at a cursor.
+type Result struct {
+	// Start and End are the byte offsets of the text in the query that is
+	// replaced by a candidate.
+	Start, End int
+	// Candidates are the candidates that match the text before the cursor.
+	Candidates []Candidate
+}
+
+// Source provides the identifiers that can be completed, along with what the
+// identifiers hold.
+type Source interface {
+	// Fields returns the candidates for the identifiers of the source.
+	Fields() []Candidate
+	// Eval returns the source of the result of running an expression against
+	// the source. Returns false if the result isn't known.
+	Eval(path.Expression) (Source, bool)
+}
+
+// FromScope creates a source from the scope a query is run against.
+func FromScope(scope path.Scope) Source {
+	return scopeSource{scope: scope}
+}
+
+// FromSchema creates a source from the schema of the scope a query is run
+// against.
+func FromSchema(s *schema.Schema) Source {
+	return schemaSource{schema: s}
+}
+
+var operators = []Candidate{
+	{Label: ".", Detail: "access a field"},
+	{Label: "..", Detail: "descend into all the fields"},
+	{Label: "[", Detail: "access an index"},
+	{Label: "==", Detail: "equal to"},
+	{Label: "!=", Detail: "not equal to"},
+	{Label: "<", Detail: "less than"},
+	{Label: "<=", Detail: "less than or equal to"},
+	{Label: ">", Detail: "greater than"},
+	{Label: ">=", Detail: "greater than or equal to"},
+	{Label: "&&", Detail: "conditional and"},
+	{Label: "||", Detail: "conditional or"},
+	{Label: ";", Detail: "start a new statement"},
+}
+
+// Complete returns the candidates for completing the query at the byte offset
+// of the cursor. The query doesn't have to be valid, only the text before the
+// cursor is used to find where the cursor is.
+func Complete(src string, offset int, source Source) Result {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(src) {
+		offset = len(src)
+	}
+
+	tokens := lex(src)
+	res := Result{
+		Start: offset,
+		End:   offset,
+	}
+
+	var (
+		word   *path.Token
+		before []path.Token
+	)
+	for i, token := range tokens {
+		if token.Pos.Offset >= offset {
+			break
+		}
+		if touches(token, offset) {
+			word = &tokens[i]
+			break
+		}
+		if token.Type != path.COMMENT {
+			before = append(before, token)
+		}
+	}
+
+	if word != nil {
+		res.Start, res.End = word.Pos.Offset, word.End().Offset
+		prefix := src[res.Start:offset]
+		switch word.Type {
+		case path.COMMENT:
+			return Result{Start: offset, End: offset}
+		case path.IDENT:
+			res.Candidates = fields(src, res.Start, before, source, func(name string) (string, bool) {
+				insert := path.QuoteIdent(name)
+				return insert, strings.HasPrefix(insert, prefix) || strings.HasPrefix(name, prefix)
+			})
+		case path.STRING:
+			prefix = prefix[1:]
+			res.Candidates = fields(src, res.Start, before, source, func(name string) (string, bool) {
+				return strconv.Quote(name), strings.HasPrefix(name, prefix)
+			})
+		default:
+			res.Candidates = filterOperators(prefix)
+		}
+		return res
+	}
+
+	if expectsOperand(before) {
+		res.Candidates = fields(src, offset, before, source, func(name string) (string, bool) {
+			return path.QuoteIdent(name), true
+		})
+	} else {
+		res.Candidates = filterOperators("")
+	}
+	return res
+}
+
+// touches returns if the cursor is within the token, or at the end of a token
+// that can be extended by typing, which starts before the cursor.
+func touches(token path.Token, offset int) bool {
+	end := token.End().Offset
+	if offset < end {
+		return true
+	}
+	if offset > end {
+		return false
+	}
+	switch token.Type {
+	case path.IDENT, path.STRING:
+		return true
+	case path.ASSIGN, path.BANG, path.LT, path.GT, path.BITAND, path.BITOR:
+		return true
+	case path.COMMENT:
+		// A line comment continues until the end of the line.
+		return !strings.HasPrefix(token.Literal, "/*")
+	}
+	return false
+}
+
+// expectsOperand returns if the next token should be an operand rather than an
+// operator.
+func expectsOperand(before []path.Token) bool {
+	if len(before) == 0 {
+		return true
+	}
+	switch before[len(before)-1].Type {
+	case path.IDENT, path.STRING, path.RPAREN, path.RBRACKET:
+		return false
+	}
+	return true
+}
+
+func filterOperators(prefix string) []Candidate {
+	var res []Candidate
+	for _, op := range operators {
+		if strings.HasPrefix(op.Label, prefix) {
+			op.Insert = op.Label
+			op.Kind = KindOperator
+			res = append(res, op)
+		}
+	}
+	return res
+}
+
+// fields returns the candidates for an identifier at the offset, using the
+// tokens before the offset to find the source the identifier is looked up in.
+func fields(src string, offset int, before []path.Token, source Source, match func(string) (string, bool)) []Candidate {
+	source, ok := resolve(src[:offset], offset, before, source)
+	if !ok {
+		return nil
+	}
+
+	var res []Candidate
+	for _, field := range source.Fields() {
+		insert, ok := match(field.Label)
+		if !ok {
+			continue
+		}
+		field.Insert = insert
+		field.Kind = KindField
+		res = append(res, field)
+	}
+	return res
+}
+
+// placeholder takes the place of the identifier being completed, so that the
+// query can be parsed.
+const placeholder = "placeholder"
+
+// resolve returns the source an identifier at the end of the text is looked
+// up in.
+func resolve(text string, offset int, before []path.Token, source Source) (Source, bool) {
+	// Close any groups and indexes that are still open, so that the text can
+	// be parsed.
+	var open []path.TokenType
+	for _, token := range before {
+		switch token.Type {
+		case path.LPAREN, path.LBRACKET:
+			open = append(open, token.Type)
+		case path.RPAREN, path.RBRACKET:
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	var closers strings.Builder
+	for i := len(open) - 1; i >= 0; i-- {
+		if open[i] == path.LPAREN {
+			closers.WriteString(")")
+		} else {
+			closers.WriteString("]")
+		}
+	}
+
+	q, err := path.Parse(text + placeholder + closers.String())
+	if err != nil {
+		// The query is broken before the cursor, so the best that can be
+		// done is to offer the identifiers of the root.
+		return source, true
+	}
+
+	nodes := path.NodesAt(q.AST(), offset)
+	for i := 0; i+1 < len(nodes); i++ {
+		var left path.Expression
+		switch parent := nodes[i].(type) {
+		case *path.AccessorExpression:
+			if nodes[i+1] == parent.Right {
+				left = parent.Left
+			}
+		case *path.IndexExpression:
+			if nodes[i+1] == parent.Index {
+				left = parent.Left
+			}
+		}
+		if left == nil {
+			continue
+		}
+		var ok bool
+		if source, ok = source.Eval(left); !ok {
+			return nil, false
+		}
+	}
+	return source, true
+}
+
+func lex(src string) []path.Token {
+	var (
+		lexer  = path.NewLexer(src)
+		tokens []path.Token
+	)
+	for {
+		token := lexer.NextToken()
+		if token.Type == path.EOF {
+			return tokens
+		}
+		tokens = append(tokens, token)
+	}
+}
+
+type scopeSource struct {
+	scope path.Scope
+}
+
+func (s scopeSource) Fields() []Candidate {
+	var (
+		res  []Candidate
+		seen = make(map[string]bool)
+	)
+	for _, ident := range s.scope.GetAllIdents() {
+		if seen[ident] {
+			continue
+		}
+		seen[ident] = true
+		res = append(res, Candidate{Label: ident})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Label < res[j].Label
+	})
+	return res
+}
+
+func (s scopeSource) Eval(e path.Expression) (Source, bool) {
+	q, err := path.FromAST(&path.QueryExpression{
+		Expressions: []path.Expression{
+			&path.ExpressionStatement{Expression: e},
+		},
+	})
+	if err != nil {
+		return nil, false
+	}
+	scope, err := q.Run(s.scope)
+	if err != nil {
+		return nil, false
+	}
+	return scopeSource{scope: scope}, true
+}
+
+type schemaSource struct {
+	schema *schema.Schema
+}
+
+func (s schemaSource) Fields() []Candidate {
+	var res []Candidate
+	if s.schema == nil {
+		return res
+	}
+	for _, name := range s.schema.FieldNames() {
+		field := s.schema.Fields[name]
+		detail := field.Schema.Type.String()
+		if field.Optional {
+			detail += ", optional"
+		}
+		res = append(res, Candidate{
+			Label:  name,
+			Detail: detail,
+		})
+	}
+	return res
+}
+
+func (s schemaSource) Eval(e path.Expression) (Source, bool) {
+	res := schema.TypeOf(e, s.schema)
+	if res == nil {
+		return nil, false
+	}
+	return schemaSource{schema: res}, true
+}
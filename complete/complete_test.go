@@ -0,0 +1,126 @@
+package complete
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spoke-d/path/schema"
+	"github.com/spoke-d/path/set"
+)
+
+func testSource() Source {
+	return FromScope(set.MakeSet(map[string]interface{}{
+		"company": map[string]interface{}{
+			"person": map[string]interface{}{
+				"name":  "fred",
+				"email": "fred@example.com",
+			},
+			"address":    "1 main street",
+			"first name": "x",
+		},
+		"config": map[string]interface{}{},
+	}))
+}
+
+func labels(res Result) []string {
+	var labels []string
+	for _, c := range res.Candidates {
+		labels = append(labels, c.Label)
+	}
+	return labels
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		expected   []string
+		start, end int
+	}{
+		{name: "empty", query: `|`, expected: []string{"company", "config"}},
+		{name: "prefix", query: `co|`, expected: []string{"company", "config"}, start: 0, end: 2},
+		{name: "narrow prefix", query: `com|`, expected: []string{"company"}, start: 0, end: 3},
+		{name: "after period", query: `company.|`, expected: []string{"address", "first name", "person"}, start: 8, end: 8},
+		{name: "nested", query: `company.person.n|`, expected: []string{"name"}, start: 15, end: 16},
+		{name: "middle of word", query: `company.pe|rson`, expected: []string{"person"}, start: 8, end: 14},
+		{name: "index", query: `company["pe|`, expected: []string{"person"}, start: 8, end: 11},
+		{name: "access", query: `company.person.[|`, expected: []string{"email", "name"}, start: 16, end: 16},
+		{name: "open group", query: `company.person.(name == "fred" && e|`, expected: []string{"email"}, start: 34, end: 35},
+		{name: "comparison", query: `company.person.(name == |`, expected: []string{"email", "name"}, start: 24, end: 24},
+		{name: "statement", query: `company.person; c|`, expected: []string{"company", "config"}, start: 16, end: 17},
+		{name: "rest ignored", query: `company.|.person`, expected: []string{"address", "first name", "person"}, start: 8, end: 8},
+		{name: "unknown", query: `missing.|`},
+		{name: "comment", query: `company # comm|`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			offset := strings.Index(test.query, "|")
+			query := strings.Replace(test.query, "|", "", 1)
+
+			res := Complete(query, offset, testSource())
+			if got := labels(res); !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+			if len(test.expected) > 0 && (res.Start != test.start || res.End != test.end) {
+				t.Errorf("expected range %d-%d, got %d-%d", test.start, test.end, res.Start, res.End)
+			}
+		})
+	}
+}
+
+func TestCompleteInsert(t *testing.T) {
+	res := Complete(`company.fir`, 11, testSource())
+	if len(res.Candidates) != 1 {
+		t.Fatalf("expected 1 candidate, got %v", res.Candidates)
+	}
+	if c := res.Candidates[0]; c.Insert != `first\ name` || c.Kind != KindField {
+		t.Errorf("unexpected candidate %+v", c)
+	}
+
+	res = Complete(`company["fir`, 12, testSource())
+	if len(res.Candidates) != 1 {
+		t.Fatalf("expected 1 candidate, got %v", res.Candidates)
+	}
+	if c := res.Candidates[0]; c.Insert != `"first name"` {
+		t.Errorf("unexpected candidate %+v", c)
+	}
+}
+
+func TestCompleteOperators(t *testing.T) {
+	res := Complete(`company `, 8, testSource())
+	if got := labels(res); len(got) != len(operators) {
+		t.Errorf("expected all operators, got %v", got)
+	}
+	for _, c := range res.Candidates {
+		if c.Kind != KindOperator {
+			t.Errorf("expected operator, got %+v", c)
+		}
+	}
+
+	res = Complete(`company.person.(name <`, 22, testSource())
+	if got, expected := labels(res), []string{"<", "<="}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if res.Start != 21 || res.End != 22 {
+		t.Errorf("unexpected range %d-%d", res.Start, res.End)
+	}
+}
+
+func TestCompleteSchema(t *testing.T) {
+	s := schema.Object(map[string]*schema.Field{
+		"users": schema.Required(schema.Map(schema.Object(map[string]*schema.Field{
+			"name": schema.Required(schema.String()),
+			"age":  schema.Optional(schema.Number()),
+		}))),
+	})
+
+	res := Complete(`users.alice.`, 12, FromSchema(s))
+	expected := []Candidate{
+		{Label: "age", Insert: "age", Kind: KindField, Detail: "number, optional"},
+		{Label: "name", Insert: "name", Kind: KindField, Detail: "string"},
+	}
+	if !reflect.DeepEqual(res.Candidates, expected) {
+		t.Errorf("expected %v, got %v", expected, res.Candidates)
+	}
+}
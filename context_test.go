@@ -0,0 +1,80 @@
+package path_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+)
+
+func TestRunContextCancelled(t *testing.T) {
+	query, err := path.Parse(`company.person.name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = query.RunContext(ctx, benchmarkScope(), path.RunOptions{})
+	if errors.Cause(err) != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRunContextLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		options path.RunOptions
+		limit   string
+	}{
+		{name: "steps", query: `company.person.name`, options: path.RunOptions{MaxSteps: 3}, limit: "steps"},
+		{name: "depth", query: `company.person.name`, options: path.RunOptions{MaxDepth: 3}, limit: "depth"},
+		{name: "results", query: `company..`, options: path.RunOptions{MaxResults: 1}, limit: "results"},
+		{name: "statements", query: `company; company`, options: path.RunOptions{MaxResults: 1}, limit: "results"},
+		{name: "scopes", query: `company..`, options: path.RunOptions{MaxScopes: 4}, limit: "scopes"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, err := path.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = query.RunContext(context.Background(), benchmarkScope(), test.options)
+			if !path.IsLimitExceeded(err) {
+				t.Fatalf("expected limit exceeded error, got %v", err)
+			}
+			if limit := errors.Cause(err).(*path.LimitExceeded).Limit; limit != test.limit {
+				t.Errorf("expected limit %q, got %q", test.limit, limit)
+			}
+		})
+	}
+}
+
+func TestRunContextWithinLimits(t *testing.T) {
+	query, err := path.Parse(`company.person.(name == "fred")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := path.RunOptions{
+		MaxSteps:   100,
+		MaxDepth:   10,
+		MaxResults: 10,
+		MaxScopes:  100,
+	}
+	result, err := query.RunContext(context.Background(), benchmarkScope(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := query.Run(benchmarkScope())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := result.GetAllIdents(), expected.GetAllIdents(); len(got) != len(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -1,6 +1,11 @@
 package path
 
-import "github.com/pkg/errors"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
 
 // RuntimeError creates an invalid error.
 type RuntimeError struct {
@@ -24,3 +29,70 @@ func IsRuntimeError(err error) bool {
 	_, ok := err.(*RuntimeError)
 	return ok
 }
+
+// ParseError is a syntax error found whilst reading or parsing a query.
+type ParseError struct {
+	// Pos is the position in the query the error was found at.
+	Pos Position
+	// Msg describes the error.
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("Syntax Error:%v %s", e.Pos, e.Msg)
+}
+
+// ParseErrors is returned by Parse when a query has syntax errors, holding
+// every error found in the order they were found.
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// NotFoundError is returned by a scope when a value isn't found. The cause of
+// the error is ErrNotFound, so it's treated as a missing value whilst keeping
+// a message about what was missing.
+type NotFoundError struct {
+	msg string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.msg
+}
+
+// Cause returns ErrNotFound.
+func (e *NotFoundError) Cause() error {
+	return ErrNotFound
+}
+
+// NotFoundErrorf creates an error for a value that isn't found.
+func NotFoundErrorf(msg string, args ...interface{}) error {
+	return &NotFoundError{
+		msg: fmt.Sprintf(msg, args...),
+	}
+}
+
+// LimitExceeded is returned when running a query exceeds one of the limits of
+// the run options.
+type LimitExceeded struct {
+	// Limit is the name of the limit that was exceeded.
+	Limit string
+	// Max is the value of the limit.
+	Max int
+}
+
+func (e *LimitExceeded) Error() string {
+	return fmt.Sprintf("Runtime Error: %s limit of %d exceeded", e.Limit, e.Max)
+}
+
+// IsLimitExceeded returns if the error is a LimitExceeded error
+func IsLimitExceeded(err error) bool {
+	err = errors.Cause(err)
+	_, ok := err.(*LimitExceeded)
+	return ok
+}
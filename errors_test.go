@@ -0,0 +1,87 @@
+package path_test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+	"github.com/spoke-d/path/set"
+)
+
+func TestNotFoundError(t *testing.T) {
+	err := errors.WithStack(path.NotFoundErrorf("no ident value %q found in scope", "aaa"))
+	if expected, got := `no ident value "aaa" found in scope`, err.Error(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	if errors.Cause(err) != path.ErrNotFound {
+		t.Errorf("expected the cause to be ErrNotFound, got %v", errors.Cause(err))
+	}
+}
+
+func TestScopeMissesAreNotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{name: "string comparison", fn: func() error {
+			_, err := path.MakeStringScope("aaa").RunOperation(path.OpEQ, path.MakeStringScope("bbb"))
+			return err
+		}},
+		{name: "scopes", fn: func() error {
+			_, err := path.NewScopes([]path.Scope{set.MakeSet(map[string]interface{}{})}).GetIdentValue("aaa")
+			return err
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.fn(); errors.Cause(err) != path.ErrNotFound {
+				t.Errorf("expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+
+	// Comparing different types is an error, rather than a miss.
+	_, err := path.MakeStringScope("aaa").RunOperation(path.OpEQ, set.MakeValue(true))
+	if err == nil || errors.Cause(err) == path.ErrNotFound {
+		t.Errorf("expected an error other than ErrNotFound, got %v", err)
+	}
+}
+
+func TestConditionalOrNotFound(t *testing.T) {
+	scope := set.MakeSet(map[string]interface{}{
+		"name":    "fred",
+		"version": "1",
+	})
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: `nickname || name`, expected: "fred"},
+		{query: `(version == "2") || name`, expected: "fred"},
+		{query: `name || nickname`, expected: "fred"},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := path.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := query.First(scope)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := path.MakeStringScope(test.expected); result != expected {
+				t.Errorf("expected %v, got %v", expected, result)
+			}
+		})
+	}
+
+	// Errors other than a missing value aren't skipped.
+	query, err := path.Parse(`(version < "true") || name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := query.Run(set.MakeSet(map[string]interface{}{"version": true, "name": "fred"})); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,164 @@
+package path
+
+import (
+	"strconv"
+	"strings"
+)
+
+// atom defines the precedence of an expression that never requires any
+// parentheses.
+const atom = INDEX + 1
+
+// Format returns the canonical source of a given expression. The source is
+// valid query syntax, with consistent spacing and quoting, so that parsing the
+// result returns an equivalent expression.
+//
+// Statements are separated by "; " on a single line, unless the query
+// contains comments, in which case each statement is written on its own line
+// with the comments preceding it. Comments that were found within a statement
+// are moved before the statement.
+func Format(e Expression) string {
+	if query, ok := e.(*QueryExpression); ok {
+		return formatQuery(query)
+	}
+	return formatExpression(e)
+}
+
+// Format returns the canonical source of the query.
+func (q Path) Format() string {
+	if q.ast == nil {
+		return ""
+	}
+	return Format(q.ast)
+}
+
+func formatQuery(query *QueryExpression) string {
+	if len(query.Comments) == 0 {
+		stmts := make([]string, len(query.Expressions))
+		for i, e := range query.Expressions {
+			stmts[i] = formatExpression(e)
+		}
+		return strings.Join(stmts, "; ")
+	}
+
+	var out strings.Builder
+	attached := make(map[*Comment]bool)
+	for i, e := range query.Expressions {
+		stmt, ok := e.(*ExpressionStatement)
+		if !ok {
+			out.WriteString(formatExpression(e))
+		} else {
+			for _, comment := range stmt.Doc {
+				attached[comment] = true
+				out.WriteString(comment.String())
+				out.WriteString("\n")
+			}
+			out.WriteString(formatExpression(stmt))
+		}
+		if i < len(query.Expressions)-1 {
+			out.WriteString(";")
+		}
+		if ok && stmt.Comment != nil {
+			attached[stmt.Comment] = true
+			out.WriteString(" ")
+			out.WriteString(stmt.Comment.String())
+		}
+		out.WriteString("\n")
+	}
+	// Any comments that are not attached to a statement are written at the
+	// end of the query.
+	for _, comment := range query.Comments {
+		if attached[comment] {
+			continue
+		}
+		out.WriteString(comment.String())
+		out.WriteString("\n")
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+func formatExpression(e Expression) string {
+	switch node := e.(type) {
+	case *QueryExpression:
+		return formatQuery(node)
+
+	case *ExpressionStatement:
+		return formatExpression(node.Expression)
+
+	case *Identifier:
+		return QuoteIdent(node.Token.Literal)
+
+	case *String:
+		return strconv.Quote(node.Token.Literal)
+
+	case *InfixExpression:
+		prec := precedence[node.Token.Type]
+		// Infix expressions are left associative, so the right hand side
+		// requires parentheses for the same precedence.
+		left := formatOperand(node.Left, prec-1)
+		right := formatExpression(node.Right)
+		if expressionPrecedence(node.Right) <= prec {
+			right = "(" + right + ")"
+		}
+		return left + " " + node.Operator + " " + right
+
+	case *AccessorExpression:
+		left := formatOperand(node.Left, INDEX-1)
+		right := formatExpression(node.Right)
+		switch node.Right.(type) {
+		case nil, *Identifier, *String, *AccessExpression, *DescentExpression, *Empty:
+		default:
+			right = "(" + right + ")"
+		}
+		return left + "." + right
+
+	case *IndexExpression:
+		return formatOperand(node.Left, INDEX-1) + "[" + formatExpression(node.Index) + "]"
+
+	case *AccessExpression:
+		return "[" + formatExpression(node.Index) + "]"
+
+	case *DescentExpression:
+		return "." + formatExpression(node.Right)
+
+	case *Empty:
+		return "()"
+	}
+	return ""
+}
+
+// formatOperand formats the left hand side of an operator, wrapping it in
+// parentheses if the expression binds less than the given precedence, or if
+// the expression would otherwise consume the operator.
+func formatOperand(e Expression, prec int) string {
+	str := formatExpression(e)
+	if expressionPrecedence(e) <= prec || isOpenEnded(e) {
+		return "(" + str + ")"
+	}
+	return str
+}
+
+// expressionPrecedence returns the precedence of a given expression.
+func expressionPrecedence(e Expression) int {
+	switch node := e.(type) {
+	case *InfixExpression:
+		return precedence[node.Token.Type]
+	case *AccessorExpression, *IndexExpression:
+		return INDEX
+	}
+	return atom
+}
+
+// isOpenEnded returns if the expression ends with a descent, which consumes
+// everything that follows it.
+func isOpenEnded(e Expression) bool {
+	switch node := e.(type) {
+	case *DescentExpression:
+		return true
+	case *InfixExpression:
+		return isOpenEnded(node.Right)
+	case *AccessorExpression:
+		return isOpenEnded(node.Right)
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package path
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: `aaa`, expected: `aaa`},
+		{input: `aaa .  bbb`, expected: `aaa.bbb`},
+		{input: `aaa;bbb;`, expected: `aaa; bbb`},
+		{input: `aaa['bbb']`, expected: `aaa["bbb"]`},
+		{input: "aaa[`b\"b`]", expected: `aaa["b\"b"]`},
+		{input: `aaa.(bbb)`, expected: `aaa.bbb`},
+		{input: `aaa.(bbb.ccc)`, expected: `aaa.(bbb.ccc)`},
+		{input: `aaa.(bbb[ccc])`, expected: `aaa.(bbb[ccc])`},
+		{input: `aaa.(["bbb"])`, expected: `aaa.["bbb"]`},
+		{input: `aaa.(bbb==ccc)`, expected: `aaa.(bbb == ccc)`},
+		{input: `(aaa || bbb).ccc`, expected: `(aaa || bbb).ccc`},
+		{input: `aaa || bbb && ccc`, expected: `aaa || bbb && ccc`},
+		{input: `(aaa || bbb) && ccc`, expected: `(aaa || bbb) && ccc`},
+		{input: `aaa && (bbb && ccc)`, expected: `aaa && (bbb && ccc)`},
+		{input: `(aaa && bbb) && ccc`, expected: `aaa && bbb && ccc`},
+		{input: `aaa...ccc`, expected: `aaa...ccc`},
+		{input: `(aaa..bbb).ccc`, expected: `(aaa..bbb).ccc`},
+		{input: `aaa.()`, expected: `aaa.()`},
+		{input: `app\.kubernetes\.io\/name`, expected: `app\.kubernetes\.io\/name`},
+		{input: "# doc\naaa ;  # trailing\n\nbbb\n# dangling", expected: "# doc\naaa; # trailing\nbbb\n# dangling"},
+		{input: "aaa\n/* inner */.bbb", expected: "/* inner */\naaa.bbb"},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			query, err := Parse(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := query.Format(); got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+			assertFormatRoundTrip(t, query)
+		})
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	res, err := ioutil.ReadFile("./testfiles/success")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewBuffer(res))
+	for scanner.Scan() {
+		line := scanner.Text()
+		t.Run(line, func(t *testing.T) {
+			query, err := Parse(line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertFormatRoundTrip(t, query)
+		})
+	}
+}
+
+func assertFormatRoundTrip(t *testing.T, query Path) {
+	t.Helper()
+
+	formatted := query.Format()
+	again, err := Parse(formatted)
+	if err != nil {
+		t.Fatalf("unexpected error parsing %q: %v", formatted, err)
+	}
+	if expected, got := dumpExpression(query.ast), dumpExpression(again.ast); expected != got {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+	if got := again.Format(); got != formatted {
+		t.Errorf("expected %q, got %q", formatted, got)
+	}
+}
+
+// dumpExpression returns the structure of an expression, ignoring positions.
+func dumpExpression(e Expression) string {
+	switch node := e.(type) {
+	case *QueryExpression:
+		var stmts []string
+		for _, e := range node.Expressions {
+			stmts = append(stmts, dumpExpression(e))
+		}
+		return fmt.Sprintf("(query %s)", strings.Join(stmts, " "))
+	case *ExpressionStatement:
+		return fmt.Sprintf("(stmt %s)", dumpExpression(node.Expression))
+	case *Identifier:
+		return fmt.Sprintf("(ident %q)", node.Token.Literal)
+	case *String:
+		return fmt.Sprintf("(string %q)", node.Token.Literal)
+	case *InfixExpression:
+		return fmt.Sprintf("(infix %s %s %s)", node.Operator, dumpExpression(node.Left), dumpExpression(node.Right))
+	case *AccessorExpression:
+		return fmt.Sprintf("(accessor %s %s)", dumpExpression(node.Left), dumpExpression(node.Right))
+	case *IndexExpression:
+		return fmt.Sprintf("(index %s %s)", dumpExpression(node.Left), dumpExpression(node.Index))
+	case *AccessExpression:
+		return fmt.Sprintf("(access %s)", dumpExpression(node.Index))
+	case *DescentExpression:
+		return fmt.Sprintf("(descent %s)", dumpExpression(node.Right))
+	case *Empty:
+		return "(empty)"
+	case nil:
+		return "nil"
+	}
+	return fmt.Sprintf("(unknown %T)", e)
+}
@@ -5,4 +5,5 @@ go 1.15
 require (
 	github.com/golang/mock v1.4.4
 	github.com/pkg/errors v0.9.1
+	gopkg.in/yaml.v3 v3.0.1
 )
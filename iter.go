@@ -0,0 +1,237 @@
+package path
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Iterator walks over the results of a query one at a time, only evaluating
+// as much of the query as is required to return the next result.
+//
+// Statements of a query, the operands of a conditional and (&&) and the
+// values of a descent are evaluated lazily, so that stopping early skips the
+// rest of the work. The results of a query are flattened, so the values
+// combined by a descent or a conditional and are returned individually.
+// If an error is found, the results returned before the error are still
+// valid, unlike Run which only returns the error.
+//
+//	iter := query.Iter(scope)
+//	for {
+//		value, ok := iter.Next()
+//		if !ok {
+//			break
+//		}
+//		...
+//	}
+//	if err := iter.Err(); err != nil {
+//		...
+//	}
+type Iterator struct {
+	runner  *runner
+	stack   []frame
+	results int
+	err     error
+}
+
+// frame is a unit of pending work for an iterator. Either the expression is
+// still to be evaluated against the scope, or the remaining values are still
+// to be returned.
+type frame struct {
+	exp    Expression
+	scope  Scope
+	idents []string
+	values []Scope
+}
+
+// Iter returns an iterator over the results of the query for a given scope.
+func (q Path) Iter(scope Scope) *Iterator {
+	return q.IterContext(context.Background(), scope, RunOptions{})
+}
+
+// IterContext returns an iterator over the results of the query for a given
+// scope, which stops if the context is done or any of the limits of the
+// options are exceeded. See RunContext for the details. As the results are
+// flattened, MaxResults limits the number of results returned by Next.
+func (q Path) IterContext(ctx context.Context, scope Scope, options RunOptions) *Iterator {
+	iter := &Iterator{
+		runner: &runner{
+			ctx:     ctx,
+			options: options,
+		},
+	}
+	if q.ast != nil {
+		iter.stack = append(iter.stack, frame{
+			exp:   q.ast,
+			scope: scope,
+		})
+	}
+	return iter
+}
+
+// Next returns the next result of the query. Returns false when there are no
+// more results, or an error was found, in which case Err returns the error.
+func (i *Iterator) Next() (Scope, bool) {
+	for i.err == nil && len(i.stack) > 0 {
+		top := &i.stack[len(i.stack)-1]
+
+		switch {
+		case top.exp != nil:
+			f := *top
+			i.stack = i.stack[:len(i.stack)-1]
+			if err := i.expand(f.exp, f.scope); err != nil {
+				i.err = errors.WithStack(err)
+			}
+
+		case len(top.idents) > 0:
+			ident := top.idents[0]
+			top.idents = top.idents[1:]
+			value, err := top.scope.GetIdentValue(ident)
+			if err != nil {
+				i.err = errors.WithStack(err)
+				break
+			}
+			if err := i.runner.produce(1); err != nil {
+				i.err = errors.WithStack(err)
+				break
+			}
+			if err := i.yield(); err != nil {
+				i.err = errors.WithStack(err)
+				break
+			}
+			return value, true
+
+		case len(top.values) > 0:
+			value := top.values[0]
+			top.values = top.values[1:]
+			if scopes, ok := value.(*Scopes); ok {
+				i.push(frame{values: scopes.scopes})
+				continue
+			}
+			if err := i.yield(); err != nil {
+				i.err = errors.WithStack(err)
+				break
+			}
+			return value, true
+
+		default:
+			i.stack = i.stack[:len(i.stack)-1]
+		}
+	}
+	return nil, false
+}
+
+// Err returns the first error found whilst iterating.
+func (i *Iterator) Err() error {
+	return i.err
+}
+
+// expand evaluates as little of the expression as possible, pushing the work
+// that remains on to the stack.
+func (i *Iterator) expand(e Expression, scope Scope) error {
+	if err := i.runner.ctx.Err(); err != nil {
+		return err
+	}
+
+	switch node := e.(type) {
+	case *QueryExpression:
+		for j := len(node.Expressions) - 1; j >= 0; j-- {
+			i.push(frame{exp: node.Expressions[j], scope: scope})
+		}
+		return nil
+
+	case *ExpressionStatement:
+		i.push(frame{exp: node.Expression, scope: scope})
+		return nil
+
+	case *DescentExpression:
+		i.push(frame{scope: scope, idents: scope.GetAllIdents()})
+		return nil
+
+	case *AccessorExpression:
+		if _, ok := node.Right.(*DescentExpression); ok {
+			parent, err := i.runner.run(node.Left, scope)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			i.push(frame{scope: parent, idents: parent.GetAllIdents()})
+			return nil
+		}
+
+	case *InfixExpression:
+		if node.Token.Type == CONDAND {
+			// The left handside is evaluated first, as any error stops the
+			// right handside from being evaluated.
+			left, err := i.runner.run(node.Left, scope)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			i.push(frame{exp: node.Right, scope: scope})
+			i.push(frame{values: []Scope{left}})
+			return nil
+		}
+	}
+
+	value, err := i.runner.run(e, scope)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	i.push(frame{values: []Scope{value}})
+	return nil
+}
+
+func (i *Iterator) push(f frame) {
+	i.stack = append(i.stack, f)
+}
+
+// yield is called before returning a result, counting it against the limit
+// of results.
+func (i *Iterator) yield() error {
+	i.results++
+	if max := i.runner.options.MaxResults; max > 0 && i.results > max {
+		return &LimitExceeded{Limit: "results", Max: max}
+	}
+	return nil
+}
+
+// Each calls the function for every result of the query, until the function
+// returns false.
+func (q Path) Each(scope Scope, fn func(Scope) bool) error {
+	iter := q.Iter(scope)
+	for {
+		value, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if !fn(value) {
+			break
+		}
+	}
+	return iter.Err()
+}
+
+// First returns the first result of the query, without evaluating the rest
+// of the query. Returns ErrNotFound if there are no results.
+func (q Path) First(scope Scope) (Scope, error) {
+	iter := q.Iter(scope)
+	if value, ok := iter.Next(); ok {
+		return value, nil
+	}
+	if err := iter.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return nil, errors.WithStack(ErrNotFound)
+}
+
+// Exists returns if the query has any results, without evaluating the rest of
+// the query once a result is found.
+func (q Path) Exists(scope Scope) (bool, error) {
+	iter := q.Iter(scope)
+	if _, ok := iter.Next(); ok {
+		return true, nil
+	}
+	if err := iter.Err(); err != nil {
+		return false, errors.WithStack(err)
+	}
+	return false, nil
+}
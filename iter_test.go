@@ -0,0 +1,181 @@
+package path_test
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+	"github.com/spoke-d/path/set"
+)
+
+func TestIter(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+	}{
+		{name: "accessor", query: `company.person.name`, expected: 1},
+		{name: "descent", query: `company..`, expected: 2},
+		{name: "statements", query: `company.person.name; company.address`, expected: 2},
+		{name: "conditional and", query: `company.person && company.address`, expected: 2},
+		{name: "descent statements", query: `company.person.name; company..`, expected: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, err := path.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var values []path.Scope
+			iter := query.Iter(benchmarkScope())
+			for {
+				value, ok := iter.Next()
+				if !ok {
+					break
+				}
+				values = append(values, value)
+			}
+			if err := iter.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if len(values) != test.expected {
+				t.Errorf("expected %d values, got %d: %v", test.expected, len(values), values)
+			}
+		})
+	}
+}
+
+func TestIterDescentValues(t *testing.T) {
+	query, err := path.Parse(`company.address..`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var values []string
+	err = query.Each(benchmarkScope(), func(value path.Scope) bool {
+		values = append(values, value.(path.StringScope).Value())
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(values)
+	if expected := []string{"1 main street", "london"}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+}
+
+func TestIterError(t *testing.T) {
+	query, err := path.Parse(`company.person.name; missing`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iter := query.Iter(benchmarkScope())
+	if _, ok := iter.Next(); !ok {
+		t.Fatalf("expected a value before the error: %v", iter.Err())
+	}
+	if _, ok := iter.Next(); ok {
+		t.Fatal("expected no more values")
+	}
+	if iter.Err() == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestIterMaxResults(t *testing.T) {
+	tests := []string{`company..`, `company.person.name; company.address`, `company.person && company.address`}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			query, err := path.Parse(test)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var num int
+			iter := query.IterContext(context.Background(), benchmarkScope(), path.RunOptions{MaxResults: 1})
+			for {
+				if _, ok := iter.Next(); !ok {
+					break
+				}
+				num++
+			}
+			if num != 1 {
+				t.Errorf("expected 1 value, got %d", num)
+			}
+			if err := iter.Err(); !path.IsLimitExceeded(err) {
+				t.Errorf("expected limit exceeded error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEachStops(t *testing.T) {
+	query, err := path.Parse(`company..`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	err = query.Each(benchmarkScope(), func(path.Scope) bool {
+		count++
+		return false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 call, got %d", count)
+	}
+}
+
+func TestFirst(t *testing.T) {
+	// The second statement would fail if it was evaluated.
+	query, err := path.Parse(`company.person.name; missing`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := query.First(benchmarkScope())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := path.MakeStringScope("fred"); value != expected {
+		t.Errorf("expected %v, got %v", expected, value)
+	}
+
+	empty, err := path.Parse(`..`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := empty.First(set.MakeSet(map[string]interface{}{})); errors.Cause(err) != path.ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestExists(t *testing.T) {
+	query, err := path.Parse(`..`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := query.Exists(benchmarkScope())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Error("expected results to exist")
+	}
+
+	ok, err = query.Exists(set.MakeSet(map[string]interface{}{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Error("expected no results")
+	}
+}
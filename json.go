@@ -0,0 +1,608 @@
+package path
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// Each expression is encoded as a JSON object with a "type" field naming the
+// expression, a "pos" field holding the position of the expression token,
+// along with the fields of the expression. Children expressions are encoded
+// in the same way, allowing the expressions to be decoded back into the same
+// types.
+//
+//	{
+//	  "type": "InfixExpression",
+//	  "pos": {"offset": 4, "line": 1, "column": 5},
+//	  "operator": "==",
+//	  "left": {"type": "Identifier", "name": "name", ...},
+//	  "right": {"type": "String", "value": "fred", ...}
+//	}
+
+const (
+	typeQueryExpression     = "QueryExpression"
+	typeExpressionStatement = "ExpressionStatement"
+	typeInfixExpression     = "InfixExpression"
+	typeAccessorExpression  = "AccessorExpression"
+	typeIndexExpression     = "IndexExpression"
+	typeAccessExpression    = "AccessExpression"
+	typeDescentExpression   = "DescentExpression"
+	typeIdentifier          = "Identifier"
+	typeString              = "String"
+	typeEmpty               = "Empty"
+	typeComment             = "Comment"
+)
+
+var operators = map[string]TokenType{
+	"==": EQ,
+	"!=": NEQ,
+	"<":  LT,
+	"<=": LE,
+	">":  GT,
+	">=": GE,
+	"&&": CONDAND,
+	"||": CONDOR,
+}
+
+// UnmarshalExpression decodes a JSON encoded expression, using the "type"
+// field to select the expression type.
+// A JSON null represents a missing expression.
+func UnmarshalExpression(data []byte) (Expression, error) {
+	if data = bytes.TrimSpace(data); len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	var header struct {
+		Type *string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if header.Type == nil {
+		return nil, errors.Errorf("missing expression type")
+	}
+
+	var e Expression
+	switch *header.Type {
+	case typeQueryExpression:
+		e = new(QueryExpression)
+	case typeExpressionStatement:
+		e = new(ExpressionStatement)
+	case typeInfixExpression:
+		e = new(InfixExpression)
+	case typeAccessorExpression:
+		e = new(AccessorExpression)
+	case typeIndexExpression:
+		e = new(IndexExpression)
+	case typeAccessExpression:
+		e = new(AccessExpression)
+	case typeDescentExpression:
+		e = new(DescentExpression)
+	case typeIdentifier:
+		e = new(Identifier)
+	case typeString:
+		e = new(String)
+	case typeEmpty:
+		e = new(Empty)
+	case typeComment:
+		e = new(Comment)
+	default:
+		return nil, errors.Errorf("unexpected expression type %q", *header.Type)
+	}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return e, nil
+}
+
+// unmarshalExpressions decodes a list of JSON encoded expressions.
+func unmarshalExpressions(data []json.RawMessage) ([]Expression, error) {
+	var res []Expression
+	for _, raw := range data {
+		e, err := UnmarshalExpression(raw)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		res = append(res, e)
+	}
+	return res, nil
+}
+
+func checkType(actual, expected string) error {
+	if actual != expected {
+		return errors.Errorf("expected expression type %q, got %q", expected, actual)
+	}
+	return nil
+}
+
+type jsonQueryExpression struct {
+	Type        string            `json:"type"`
+	Expressions []json.RawMessage `json:"expressions"`
+	Comments    []*Comment        `json:"comments,omitempty"`
+}
+
+// MarshalJSON encodes the query expression as JSON.
+func (e *QueryExpression) MarshalJSON() ([]byte, error) {
+	exps, err := marshalExpressions(e.Expressions)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return json.Marshal(jsonQueryExpression{
+		Type:        typeQueryExpression,
+		Expressions: exps,
+		Comments:    e.Comments,
+	})
+}
+
+// UnmarshalJSON decodes the query expression from JSON.
+func (e *QueryExpression) UnmarshalJSON(data []byte) error {
+	var raw jsonQueryExpression
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := checkType(raw.Type, typeQueryExpression); err != nil {
+		return errors.WithStack(err)
+	}
+	exps, err := unmarshalExpressions(raw.Expressions)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	*e = QueryExpression{
+		Expressions: exps,
+		Comments:    raw.Comments,
+	}
+	return nil
+}
+
+type jsonExpressionStatement struct {
+	Type       string          `json:"type"`
+	Pos        Position        `json:"pos"`
+	Expression json.RawMessage `json:"expression"`
+	Doc        []*Comment      `json:"doc,omitempty"`
+	Comment    *Comment        `json:"comment,omitempty"`
+}
+
+// MarshalJSON encodes the expression statement as JSON.
+func (es *ExpressionStatement) MarshalJSON() ([]byte, error) {
+	exp, err := marshalExpression(es.Expression)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return json.Marshal(jsonExpressionStatement{
+		Type:       typeExpressionStatement,
+		Pos:        es.Token.Pos,
+		Expression: exp,
+		Doc:        es.Doc,
+		Comment:    es.Comment,
+	})
+}
+
+// UnmarshalJSON decodes the expression statement from JSON.
+func (es *ExpressionStatement) UnmarshalJSON(data []byte) error {
+	var raw jsonExpressionStatement
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := checkType(raw.Type, typeExpressionStatement); err != nil {
+		return errors.WithStack(err)
+	}
+	exp, err := UnmarshalExpression(raw.Expression)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	*es = ExpressionStatement{
+		Token:      Token{Pos: raw.Pos},
+		Expression: exp,
+		Doc:        raw.Doc,
+		Comment:    raw.Comment,
+	}
+	return nil
+}
+
+type jsonInfixExpression struct {
+	Type     string          `json:"type"`
+	Pos      Position        `json:"pos"`
+	Operator string          `json:"operator"`
+	Left     json.RawMessage `json:"left"`
+	Right    json.RawMessage `json:"right"`
+}
+
+// MarshalJSON encodes the infix expression as JSON.
+func (ie *InfixExpression) MarshalJSON() ([]byte, error) {
+	left, err := marshalExpression(ie.Left)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	right, err := marshalExpression(ie.Right)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return json.Marshal(jsonInfixExpression{
+		Type:     typeInfixExpression,
+		Pos:      ie.Token.Pos,
+		Operator: ie.Operator,
+		Left:     left,
+		Right:    right,
+	})
+}
+
+// UnmarshalJSON decodes the infix expression from JSON.
+func (ie *InfixExpression) UnmarshalJSON(data []byte) error {
+	var raw jsonInfixExpression
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := checkType(raw.Type, typeInfixExpression); err != nil {
+		return errors.WithStack(err)
+	}
+	tokenType, ok := operators[raw.Operator]
+	if !ok {
+		return errors.Errorf("unexpected operator %q", raw.Operator)
+	}
+	left, err := UnmarshalExpression(raw.Left)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	right, err := UnmarshalExpression(raw.Right)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	*ie = InfixExpression{
+		Token: Token{
+			Pos:     raw.Pos,
+			Type:    tokenType,
+			Literal: raw.Operator,
+		},
+		Operator: raw.Operator,
+		Left:     left,
+		Right:    right,
+	}
+	return nil
+}
+
+type jsonAccessorExpression struct {
+	Type  string          `json:"type"`
+	Pos   Position        `json:"pos"`
+	Left  json.RawMessage `json:"left"`
+	Right json.RawMessage `json:"right"`
+}
+
+// MarshalJSON encodes the accessor expression as JSON.
+func (ie *AccessorExpression) MarshalJSON() ([]byte, error) {
+	left, err := marshalExpression(ie.Left)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	right, err := marshalExpression(ie.Right)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return json.Marshal(jsonAccessorExpression{
+		Type:  typeAccessorExpression,
+		Pos:   ie.Token.Pos,
+		Left:  left,
+		Right: right,
+	})
+}
+
+// UnmarshalJSON decodes the accessor expression from JSON.
+func (ie *AccessorExpression) UnmarshalJSON(data []byte) error {
+	var raw jsonAccessorExpression
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := checkType(raw.Type, typeAccessorExpression); err != nil {
+		return errors.WithStack(err)
+	}
+	left, err := UnmarshalExpression(raw.Left)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	right, err := UnmarshalExpression(raw.Right)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	*ie = AccessorExpression{
+		Token: Token{
+			Pos:     raw.Pos,
+			Type:    PERIOD,
+			Literal: ".",
+		},
+		Left:  left,
+		Right: right,
+	}
+	return nil
+}
+
+type jsonIndexExpression struct {
+	Type  string          `json:"type"`
+	Pos   Position        `json:"pos"`
+	Left  json.RawMessage `json:"left,omitempty"`
+	Index json.RawMessage `json:"index"`
+}
+
+// MarshalJSON encodes the index expression as JSON.
+func (ie *IndexExpression) MarshalJSON() ([]byte, error) {
+	left, err := marshalExpression(ie.Left)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	index, err := marshalExpression(ie.Index)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return json.Marshal(jsonIndexExpression{
+		Type:  typeIndexExpression,
+		Pos:   ie.Token.Pos,
+		Left:  left,
+		Index: index,
+	})
+}
+
+// UnmarshalJSON decodes the index expression from JSON.
+func (ie *IndexExpression) UnmarshalJSON(data []byte) error {
+	var raw jsonIndexExpression
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := checkType(raw.Type, typeIndexExpression); err != nil {
+		return errors.WithStack(err)
+	}
+	left, err := UnmarshalExpression(raw.Left)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	index, err := UnmarshalExpression(raw.Index)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	*ie = IndexExpression{
+		Token: Token{
+			Pos:     raw.Pos,
+			Type:    LBRACKET,
+			Literal: "[",
+		},
+		Left:  left,
+		Index: index,
+	}
+	return nil
+}
+
+// MarshalJSON encodes the access expression as JSON.
+func (ie *AccessExpression) MarshalJSON() ([]byte, error) {
+	index, err := marshalExpression(ie.Index)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return json.Marshal(jsonIndexExpression{
+		Type:  typeAccessExpression,
+		Pos:   ie.Token.Pos,
+		Index: index,
+	})
+}
+
+// UnmarshalJSON decodes the access expression from JSON.
+func (ie *AccessExpression) UnmarshalJSON(data []byte) error {
+	var raw jsonIndexExpression
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := checkType(raw.Type, typeAccessExpression); err != nil {
+		return errors.WithStack(err)
+	}
+	index, err := UnmarshalExpression(raw.Index)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	*ie = AccessExpression{
+		Token: Token{
+			Pos:     raw.Pos,
+			Type:    LBRACKET,
+			Literal: "[",
+		},
+		Index: index,
+	}
+	return nil
+}
+
+type jsonDescentExpression struct {
+	Type  string          `json:"type"`
+	Pos   Position        `json:"pos"`
+	Right json.RawMessage `json:"right"`
+}
+
+// MarshalJSON encodes the descent expression as JSON.
+func (i *DescentExpression) MarshalJSON() ([]byte, error) {
+	right, err := marshalExpression(i.Right)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return json.Marshal(jsonDescentExpression{
+		Type:  typeDescentExpression,
+		Pos:   i.Token.Pos,
+		Right: right,
+	})
+}
+
+// UnmarshalJSON decodes the descent expression from JSON.
+func (i *DescentExpression) UnmarshalJSON(data []byte) error {
+	var raw jsonDescentExpression
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := checkType(raw.Type, typeDescentExpression); err != nil {
+		return errors.WithStack(err)
+	}
+	right, err := UnmarshalExpression(raw.Right)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	*i = DescentExpression{
+		Token: Token{
+			Pos:     raw.Pos,
+			Type:    PERIOD,
+			Literal: ".",
+		},
+		Right: right,
+	}
+	return nil
+}
+
+type jsonIdentifier struct {
+	Type string   `json:"type"`
+	Pos  Position `json:"pos"`
+	Name string   `json:"name"`
+}
+
+// MarshalJSON encodes the identifier as JSON.
+func (i *Identifier) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonIdentifier{
+		Type: typeIdentifier,
+		Pos:  i.Token.Pos,
+		Name: i.Token.Literal,
+	})
+}
+
+// UnmarshalJSON decodes the identifier from JSON.
+func (i *Identifier) UnmarshalJSON(data []byte) error {
+	var raw jsonIdentifier
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := checkType(raw.Type, typeIdentifier); err != nil {
+		return errors.WithStack(err)
+	}
+	*i = Identifier{
+		Token: Token{
+			Pos:     raw.Pos,
+			Type:    IDENT,
+			Literal: raw.Name,
+		},
+	}
+	return nil
+}
+
+type jsonString struct {
+	Type  string   `json:"type"`
+	Pos   Position `json:"pos"`
+	Value string   `json:"value"`
+}
+
+// MarshalJSON encodes the string as JSON.
+func (i *String) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonString{
+		Type:  typeString,
+		Pos:   i.Token.Pos,
+		Value: i.Token.Literal,
+	})
+}
+
+// UnmarshalJSON decodes the string from JSON.
+func (i *String) UnmarshalJSON(data []byte) error {
+	var raw jsonString
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := checkType(raw.Type, typeString); err != nil {
+		return errors.WithStack(err)
+	}
+	*i = String{
+		Token: Token{
+			Pos:     raw.Pos,
+			Type:    STRING,
+			Literal: raw.Value,
+		},
+	}
+	return nil
+}
+
+type jsonEmpty struct {
+	Type string   `json:"type"`
+	Pos  Position `json:"pos"`
+}
+
+// MarshalJSON encodes the empty expression as JSON.
+func (i *Empty) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonEmpty{
+		Type: typeEmpty,
+		Pos:  i.Token.Pos,
+	})
+}
+
+// UnmarshalJSON decodes the empty expression from JSON.
+func (i *Empty) UnmarshalJSON(data []byte) error {
+	var raw jsonEmpty
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := checkType(raw.Type, typeEmpty); err != nil {
+		return errors.WithStack(err)
+	}
+	*i = Empty{
+		Token: Token{
+			Pos:     raw.Pos,
+			Type:    RPAREN,
+			Literal: ")",
+		},
+	}
+	return nil
+}
+
+type jsonComment struct {
+	Type    string   `json:"type"`
+	Pos     Position `json:"pos"`
+	Literal string   `json:"literal"`
+}
+
+// MarshalJSON encodes the comment as JSON.
+func (c *Comment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonComment{
+		Type:    typeComment,
+		Pos:     c.Token.Pos,
+		Literal: c.Token.Literal,
+	})
+}
+
+// UnmarshalJSON decodes the comment from JSON.
+func (c *Comment) UnmarshalJSON(data []byte) error {
+	var raw jsonComment
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := checkType(raw.Type, typeComment); err != nil {
+		return errors.WithStack(err)
+	}
+	*c = Comment{
+		Token: Token{
+			Pos:     raw.Pos,
+			Type:    COMMENT,
+			Literal: raw.Literal,
+		},
+	}
+	return nil
+}
+
+func marshalExpression(e Expression) (json.RawMessage, error) {
+	if e == nil {
+		return json.RawMessage("null"), nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return data, nil
+}
+
+func marshalExpressions(exps []Expression) ([]json.RawMessage, error) {
+	res := make([]json.RawMessage, len(exps))
+	for i, e := range exps {
+		data, err := marshalExpression(e)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		res[i] = data
+	}
+	return res, nil
+}
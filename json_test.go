@@ -0,0 +1,199 @@
+package path
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	res, err := ioutil.ReadFile("./testfiles/success")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewBuffer(res))
+	for scanner.Scan() {
+		line := scanner.Text()
+		t.Run(line, func(t *testing.T) {
+			query, err := Parse(line)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := json.Marshal(query.AST())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ast QueryExpression
+			if err := json.Unmarshal(data, &ast); err != nil {
+				t.Fatal(err)
+			}
+			if expected, got := dumpExpression(query.AST()), dumpExpression(&ast); expected != got {
+				t.Errorf("expected %s, got %s", expected, got)
+			}
+
+			again, err := json.Marshal(&ast)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, again) {
+				t.Errorf("expected %s, got %s", data, again)
+			}
+		})
+	}
+}
+
+func TestJSONPositions(t *testing.T) {
+	query, err := Parse("# doc\naaa == 'x'")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(query.AST())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := UnmarshalExpression(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt := e.(*QueryExpression).Expressions[0].(*ExpressionStatement)
+	infix := stmt.Expression.(*InfixExpression)
+	if expected, got := (Position{Offset: 10, Line: 2, Column: 5}), infix.Pos(); got != expected {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if expected, got := (Position{Offset: 16, Line: 2, Column: 11}), infix.End(); got != expected {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if len(stmt.Doc) != 1 || stmt.Doc[0].Text() != "doc" {
+		t.Errorf("unexpected doc %v", stmt.Doc)
+	}
+}
+
+// TestJSONOperatorPositions pins the positions of the operator expressions,
+// which are the positions of their operator tokens rather than of their
+// operands.
+func TestJSONOperatorPositions(t *testing.T) {
+	query, err := Parse("aaa.bbb[ccc]; [ddd]; ..eee")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(query.AST())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := UnmarshalExpression(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	Inspect(e, func(e Expression) bool {
+		switch e.(type) {
+		case *AccessorExpression, *IndexExpression, *AccessExpression, *DescentExpression:
+			got = append(got, fmt.Sprintf("%T%v", e, e.Pos()))
+		}
+		return true
+	})
+	expected := []string{
+		"*path.IndexExpression<:1:8>",
+		"*path.AccessorExpression<:1:4>",
+		"*path.AccessExpression<:1:15>",
+		"*path.DescentExpression<:1:22>",
+		"*path.DescentExpression<:1:23>",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestFromAST(t *testing.T) {
+	data := []byte(`{
+		"type": "QueryExpression",
+		"expressions": [{
+			"type": "AccessorExpression",
+			"left": {"type": "Identifier", "name": "aaa"},
+			"right": {
+				"type": "InfixExpression",
+				"operator": "==",
+				"left": {"type": "Identifier", "name": "bbb"},
+				"right": {"type": "String", "value": "bbb"}
+			}
+		}]
+	}`)
+
+	var ast QueryExpression
+	if err := json.Unmarshal(data, &ast); err != nil {
+		t.Fatal(err)
+	}
+
+	query, err := FromAST(&ast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, got := `aaa.(bbb == "bbb")`, query.Format(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	// Anything that can be parsed is valid.
+	for _, src := range []string{`aaa.()`, `()`, "# doc\naaa.bbb"} {
+		t.Run(src, func(t *testing.T) {
+			query, err := Parse(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := FromAST(query.AST())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected, got := query.Format(), result.Format(); got != expected {
+				t.Errorf("expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestFromASTInvalid(t *testing.T) {
+	tests := []string{
+		`{"type": "QueryExpression", "expressions": [null]}`,
+		`{"type": "QueryExpression", "expressions": [{"type": "Identifier", "name": ""}]}`,
+		`{"type": "QueryExpression", "expressions": [{"type": "AccessorExpression", "left": {"type": "Identifier", "name": "aaa"}}]}`,
+		`{"type": "QueryExpression", "expressions": [{"type": "Comment", "literal": "# aaa"}]}`,
+		`{"type": "QueryExpression", "expressions": [{"type": "AccessorExpression", "left": {"type": "Identifier", "name": "aaa"}, "right": {"type": "Comment", "literal": "# bbb"}}]}`,
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			var ast QueryExpression
+			if err := json.Unmarshal([]byte(test), &ast); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := FromAST(&ast); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestUnmarshalExpressionErrors(t *testing.T) {
+	tests := []string{
+		`{}`,
+		`{"type": "Unknown"}`,
+		`{"type": "InfixExpression", "operator": "+"}`,
+		`{"type": "QueryExpression", "expressions": [{"name": "aaa"}]}`,
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			if _, err := UnmarshalExpression([]byte(test)); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package jsonpatch
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path/set"
+)
+
+// Apply applies the patch to the set. The patch is applied atomically, so if
+// any operation fails the set is left unchanged.
+func Apply(s set.Set, patch Patch) error {
+	doc, err := document(s)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var res interface{} = deepCopy(doc)
+	for i, op := range patch {
+		if res, err = applyOperation(res, op); err != nil {
+			return errors.Wrapf(err, "operation %d", i)
+		}
+	}
+	m, ok := res.(map[string]interface{})
+	if !ok {
+		return errors.Errorf("unexpected document %T", res)
+	}
+
+	for k := range doc {
+		delete(doc, k)
+	}
+	for k, v := range m {
+		doc[k] = v
+	}
+	return nil
+}
+
+func applyOperation(doc interface{}, op Operation) (interface{}, error) {
+	tokens, err := ParsePointer(op.Path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	switch op.Op {
+	case OpAdd:
+		return add(doc, tokens, deepCopy(op.Value))
+
+	case OpRemove:
+		res, _, err := remove(doc, tokens)
+		return res, err
+
+	case OpReplace:
+		res, _, err := remove(doc, tokens)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return add(res, tokens, deepCopy(op.Value))
+
+	case OpMove:
+		from, err := ParsePointer(op.From)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if isPrefix(from, tokens) && len(from) < len(tokens) {
+			return nil, errors.Errorf("unable to move %q into itself", op.From)
+		}
+		res, value, err := remove(doc, from)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return add(res, tokens, value)
+
+	case OpCopy:
+		from, err := ParsePointer(op.From)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return add(doc, tokens, deepCopy(value))
+
+	case OpTest:
+		value, err := get(doc, tokens)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if !equal(value, op.Value) {
+			return nil, errors.Errorf("test of %q failed", op.Path)
+		}
+		return doc, nil
+	}
+	return nil, errors.Errorf("unexpected operation %q", op.Op)
+}
+
+// get returns the value at the location of the tokens.
+func get(doc interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch t := doc.(type) {
+		case map[string]interface{}:
+			v, ok := t[token]
+			if !ok {
+				return nil, errors.Errorf("member %q not found", token)
+			}
+			doc = v
+		case []interface{}:
+			i, err := arrayIndex(token, len(t))
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			doc = t[i]
+		default:
+			return nil, errors.Errorf("unable to access %q of %T", token, doc)
+		}
+	}
+	return doc, nil
+}
+
+// add adds the value at the location of the tokens, returning the changed
+// document.
+func add(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	token, rest := tokens[0], tokens[1:]
+	switch t := doc.(type) {
+	case map[string]interface{}:
+		if len(rest) == 0 {
+			t[token] = value
+			return t, nil
+		}
+		child, ok := t[token]
+		if !ok {
+			return nil, errors.Errorf("member %q not found", token)
+		}
+		res, err := add(child, rest, value)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		t[token] = res
+		return t, nil
+
+	case []interface{}:
+		if len(rest) == 0 {
+			i := len(t)
+			if token != "-" {
+				var err error
+				if i, err = arrayIndex(token, len(t)+1); err != nil {
+					return nil, errors.WithStack(err)
+				}
+			}
+			res := make([]interface{}, 0, len(t)+1)
+			res = append(res, t[:i]...)
+			res = append(res, value)
+			return append(res, t[i:]...), nil
+		}
+		i, err := arrayIndex(token, len(t))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		res, err := add(t[i], rest, value)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		t[i] = res
+		return t, nil
+	}
+	return nil, errors.Errorf("unable to add %q to %T", token, doc)
+}
+
+// remove removes the value at the location of the tokens, returning the
+// changed document and the value removed.
+func remove(doc interface{}, tokens []string) (interface{}, interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, doc, nil
+	}
+
+	token, rest := tokens[0], tokens[1:]
+	switch t := doc.(type) {
+	case map[string]interface{}:
+		child, ok := t[token]
+		if !ok {
+			return nil, nil, errors.Errorf("member %q not found", token)
+		}
+		if len(rest) == 0 {
+			delete(t, token)
+			return t, child, nil
+		}
+		res, value, err := remove(child, rest)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		t[token] = res
+		return t, value, nil
+
+	case []interface{}:
+		i, err := arrayIndex(token, len(t))
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		if len(rest) == 0 {
+			res := make([]interface{}, 0, len(t)-1)
+			res = append(res, t[:i]...)
+			return append(res, t[i+1:]...), t[i], nil
+		}
+		res, value, err := remove(t[i], rest)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		t[i] = res
+		return t, value, nil
+	}
+	return nil, nil, errors.Errorf("unable to remove %q from %T", token, doc)
+}
+
+func isPrefix(prefix, tokens []string) bool {
+	if len(prefix) > len(tokens) {
+		return false
+	}
+	for i, token := range prefix {
+		if tokens[i] != token {
+			return false
+		}
+	}
+	return true
+}
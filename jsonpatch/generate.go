@@ -0,0 +1,61 @@
+package jsonpatch
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+	"github.com/spoke-d/path/set"
+)
+
+// Set returns the patch that sets every value matched by the query to the
+// given value, without modifying the set. See path.Path.Set.
+func Set(q path.Path, s set.Set, value path.Scope) (Patch, error) {
+	return generate(s, func(scope path.Scope) error {
+		_, err := q.Set(scope, value)
+		return err
+	})
+}
+
+// Update returns the patch that replaces every value matched by the query
+// with the result of the function, without modifying the set. See
+// path.Path.Update.
+func Update(q path.Path, s set.Set, fn func(path.Scope) path.Scope) (Patch, error) {
+	return generate(s, func(scope path.Scope) error {
+		_, err := q.Update(scope, fn)
+		return err
+	})
+}
+
+// Delete returns the patch that removes every value matched by the query,
+// without modifying the set. See path.Path.Delete.
+func Delete(q path.Path, s set.Set) (Patch, error) {
+	return generate(s, func(scope path.Scope) error {
+		_, err := q.Delete(scope)
+		return err
+	})
+}
+
+// generate runs the change against a copy of the set, returning the
+// difference between the set and the copy.
+func generate(s set.Set, change func(path.Scope) error) (Patch, error) {
+	from, err := document(s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	to := deepCopy(from).(map[string]interface{})
+	if err := change(set.MakeSet(to)); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return Diff(from, to), nil
+}
+
+func document(s set.Set) (map[string]interface{}, error) {
+	v, err := set.Unlift(s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("unexpected document %T", v)
+	}
+	return m, nil
+}
@@ -0,0 +1,314 @@
+// Package jsonpatch describes the changes made by queries to a set.Set as RFC
+// 6902 JSON Patch documents, and applies JSON Patch documents to a set.Set.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+)
+
+// The operations of a JSON Patch document.
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+// Operation is a single operation of a JSON Patch document.
+type Operation struct {
+	// Op is the name of the operation.
+	Op string
+	// Path is the JSON Pointer to the location the operation applies to.
+	Path string
+	// From is the JSON Pointer to the location to move or copy from.
+	From string
+	// Value is the value to add, replace or test with.
+	Value interface{}
+}
+
+// MarshalJSON encodes the operation, only including the members used by the
+// operation.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{
+		"op":   o.Op,
+		"path": o.Path,
+	}
+	switch o.Op {
+	case OpAdd, OpReplace, OpTest:
+		m["value"] = o.Value
+	case OpMove, OpCopy:
+		m["from"] = o.From
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes the operation, checking that the members required by
+// the operation are present.
+func (o *Operation) UnmarshalJSON(b []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return errors.WithStack(err)
+	}
+
+	var op Operation
+	if err := unmarshalMember(m, "op", &op.Op); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := unmarshalMember(m, "path", &op.Path); err != nil {
+		return errors.WithStack(err)
+	}
+	switch op.Op {
+	case OpAdd, OpReplace, OpTest:
+		if err := unmarshalMember(m, "value", &op.Value); err != nil {
+			return errors.WithStack(err)
+		}
+	case OpMove, OpCopy:
+		if err := unmarshalMember(m, "from", &op.From); err != nil {
+			return errors.WithStack(err)
+		}
+	case OpRemove:
+	default:
+		return errors.Errorf("unexpected operation %q", op.Op)
+	}
+	*o = op
+	return nil
+}
+
+func unmarshalMember(m map[string]json.RawMessage, name string, v interface{}) error {
+	raw, ok := m[name]
+	if !ok {
+		return errors.Errorf("missing %q member", name)
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// Patch is a JSON Patch document, which is a list of operations applied in
+// order.
+type Patch []Operation
+
+// Pointer returns the JSON Pointer for a location.
+func Pointer(location path.Location) string {
+	var buf strings.Builder
+	for _, ident := range location {
+		buf.WriteString("/")
+		buf.WriteString(escape(ident))
+	}
+	return buf.String()
+}
+
+// ParsePointer returns the reference tokens of a JSON Pointer.
+func ParsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, errors.Errorf("invalid pointer %q, expected leading /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = unescape(token)
+	}
+	return tokens, nil
+}
+
+var (
+	escaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	unescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+func escape(token string) string {
+	return escaper.Replace(token)
+}
+
+func unescape(token string) string {
+	return unescaper.Replace(token)
+}
+
+// Diff returns the patch that changes one document into another. Objects are
+// compared member by member, so only the members that changed are included,
+// everything else is replaced as a whole.
+func Diff(from, to map[string]interface{}) Patch {
+	var patch Patch
+	diffObjects("", from, to, &patch)
+	return patch
+}
+
+func diff(pointer string, from, to interface{}, patch *Patch) {
+	fromObject, ok := from.(map[string]interface{})
+	if toObject, isObject := to.(map[string]interface{}); ok && isObject {
+		diffObjects(pointer, fromObject, toObject, patch)
+		return
+	}
+	if !equal(from, to) {
+		*patch = append(*patch, Operation{
+			Op:    OpReplace,
+			Path:  pointer,
+			Value: deepCopy(to),
+		})
+	}
+}
+
+func diffObjects(pointer string, from, to map[string]interface{}, patch *Patch) {
+	for _, k := range sortedKeys(from) {
+		member := pointer + "/" + escape(k)
+		if _, ok := to[k]; !ok {
+			*patch = append(*patch, Operation{
+				Op:   OpRemove,
+				Path: member,
+			})
+			continue
+		}
+		diff(member, from[k], to[k], patch)
+	}
+	for _, k := range sortedKeys(to) {
+		if _, ok := from[k]; ok {
+			continue
+		}
+		*patch = append(*patch, Operation{
+			Op:    OpAdd,
+			Path:  pointer + "/" + escape(k),
+			Value: deepCopy(to[k]),
+		})
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// deepCopy copies the objects and arrays of a value, so that changing the
+// copy doesn't change the original.
+func deepCopy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = deepCopy(v)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, v := range t {
+			a[i] = deepCopy(v)
+		}
+		return a
+	}
+	return v
+}
+
+// equal reports whether two values are the same JSON value. Numbers are
+// equal when their values are equal, whatever their Go type, see RFC 6902
+// section 4.6.
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x.Cmp(y) == 0
+	}
+	if _, ok := number(b); ok {
+		return false
+	}
+	switch a.(type) {
+	case nil, bool, string:
+		return a == b
+	}
+	return false
+}
+
+// numberPrecision is the number of bits numbers are compared with, so that
+// large integers and decimals aren't rounded to the same float64.
+const numberPrecision = 512
+
+// number returns the value of a number of any Go type.
+func number(v interface{}) (*big.Float, bool) {
+	f := new(big.Float).SetPrec(numberPrecision)
+	switch t := v.(type) {
+	case json.Number:
+		if _, ok := f.SetString(string(t)); !ok {
+			return nil, false
+		}
+		return f, true
+	case float64:
+		if math.IsNaN(t) {
+			return nil, false
+		}
+		return f.SetFloat64(t), true
+	case float32:
+		if math.IsNaN(float64(t)) {
+			return nil, false
+		}
+		return f.SetFloat64(float64(t)), true
+	case int:
+		return f.SetInt64(int64(t)), true
+	case int8:
+		return f.SetInt64(int64(t)), true
+	case int16:
+		return f.SetInt64(int64(t)), true
+	case int32:
+		return f.SetInt64(int64(t)), true
+	case int64:
+		return f.SetInt64(t), true
+	case uint:
+		return f.SetUint64(uint64(t)), true
+	case uint8:
+		return f.SetUint64(uint64(t)), true
+	case uint16:
+		return f.SetUint64(uint64(t)), true
+	case uint32:
+		return f.SetUint64(uint64(t)), true
+	case uint64:
+		return f.SetUint64(t), true
+	}
+	return nil, false
+}
+
+// arrayIndex returns the index of an array for a reference token.
+func arrayIndex(token string, length int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, errors.Errorf("invalid array index %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i >= length {
+		return 0, errors.Errorf("array index %q out of range", token)
+	}
+	return i, nil
+}
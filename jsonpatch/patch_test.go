@@ -0,0 +1,291 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/spoke-d/path"
+	"github.com/spoke-d/path/set"
+)
+
+func testDocument() map[string]interface{} {
+	return map[string]interface{}{
+		"users": map[string]interface{}{
+			"alice": map[string]interface{}{
+				"role": "admin",
+			},
+			"bob": map[string]interface{}{
+				"role": "user",
+			},
+		},
+	}
+}
+
+func TestPointer(t *testing.T) {
+	location := path.Location{"users", "a/b", "c~d"}
+	pointer := Pointer(location)
+	if expected := "/users/a~1b/c~0d"; pointer != expected {
+		t.Errorf("expected %q, got %q", expected, pointer)
+	}
+	tokens, err := ParsePointer(pointer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(path.Location(tokens), location) {
+		t.Errorf("expected %v, got %v", location, tokens)
+	}
+	if _, err := ParsePointer("users"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	from := testDocument()
+	to := testDocument()
+	to["users"].(map[string]interface{})["bob"].(map[string]interface{})["role"] = "admin"
+	delete(to["users"].(map[string]interface{}), "alice")
+	to["version"] = "2"
+
+	patch := Diff(from, to)
+	expected := Patch{
+		{Op: OpRemove, Path: "/users/alice"},
+		{Op: OpReplace, Path: "/users/bob/role", Value: "admin"},
+		{Op: OpAdd, Path: "/version", Value: "2"},
+	}
+	if !reflect.DeepEqual(patch, expected) {
+		t.Errorf("expected %v, got %v", expected, patch)
+	}
+
+	from = map[string]interface{}{"version": json.Number("1")}
+	to = map[string]interface{}{"version": float64(1)}
+	if patch := Diff(from, to); len(patch) != 0 {
+		t.Errorf("expected empty patch, got %v", patch)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	doc := testDocument()
+	s := set.MakeSet(doc)
+
+	query, err := path.Parse(`users..`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch, err := Set(query, s, path.MakeStringScope("removed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Patch{
+		{Op: OpReplace, Path: "/users/alice", Value: "removed"},
+		{Op: OpReplace, Path: "/users/bob", Value: "removed"},
+	}
+	if !reflect.DeepEqual(patch, expected) {
+		t.Errorf("expected %v, got %v", expected, patch)
+	}
+
+	query, err = path.Parse(`users.alice.role`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch, err = Delete(query, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = Patch{
+		{Op: OpRemove, Path: "/users/alice/role"},
+	}
+	if !reflect.DeepEqual(patch, expected) {
+		t.Errorf("expected %v, got %v", expected, patch)
+	}
+
+	patch, err = Update(query, s, func(value path.Scope) path.Scope {
+		return value
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patch) != 0 {
+		t.Errorf("expected empty patch, got %v", patch)
+	}
+
+	if !reflect.DeepEqual(doc, testDocument()) {
+		t.Errorf("expected document to be unchanged, got %v", doc)
+	}
+}
+
+func TestGenerateFiltered(t *testing.T) {
+	doc := map[string]interface{}{
+		"env": map[string]interface{}{
+			"a": "prod",
+			"b": "dev",
+		},
+		"keep": "x",
+	}
+	s := set.MakeSet(doc)
+
+	query, err := path.Parse(`(env == "prod")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch, err := Delete(query, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Patch{
+		{Op: OpRemove, Path: "/env/a"},
+	}
+	if !reflect.DeepEqual(patch, expected) {
+		t.Errorf("expected %v, got %v", expected, patch)
+	}
+
+	patch, err = Set(query, s, path.MakeStringScope("live"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = Patch{
+		{Op: OpReplace, Path: "/env/a", Value: "live"},
+	}
+	if !reflect.DeepEqual(patch, expected) {
+		t.Errorf("expected %v, got %v", expected, patch)
+	}
+}
+
+func TestApply(t *testing.T) {
+	doc := testDocument()
+	s := set.MakeSet(doc)
+
+	var patch Patch
+	src := `[
+		{"op": "test", "path": "/users/bob/role", "value": "user"},
+		{"op": "add", "path": "/users/carol", "value": {"role": "user"}},
+		{"op": "copy", "from": "/users/carol", "path": "/users/dave"},
+		{"op": "move", "from": "/users/alice", "path": "/admins"},
+		{"op": "replace", "path": "/users/bob/role", "value": "admin"},
+		{"op": "remove", "path": "/users/dave/role"}
+	]`
+	if err := json.Unmarshal([]byte(src), &patch); err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(s, patch); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"admins": map[string]interface{}{
+			"role": "admin",
+		},
+		"users": map[string]interface{}{
+			"bob": map[string]interface{}{
+				"role": "admin",
+			},
+			"carol": map[string]interface{}{
+				"role": "user",
+			},
+			"dave": map[string]interface{}{},
+		},
+	}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("expected %v, got %v", expected, doc)
+	}
+}
+
+func TestApplyAtomic(t *testing.T) {
+	doc := testDocument()
+	patch := Patch{
+		{Op: OpRemove, Path: "/users/alice"},
+		{Op: OpTest, Path: "/users/bob/role", Value: "admin"},
+	}
+	if err := Apply(set.MakeSet(doc), patch); err == nil {
+		t.Fatal("expected error")
+	}
+	if !reflect.DeepEqual(doc, testDocument()) {
+		t.Errorf("expected document to be unchanged, got %v", doc)
+	}
+}
+
+func TestApplyTestNumbers(t *testing.T) {
+	doc := map[string]interface{}{
+		"version": json.Number("1"),
+		"ratio":   json.Number("0.50"),
+		"list":    []interface{}{json.Number("1e2"), "a"},
+	}
+	testCases := []struct {
+		name  string
+		path  string
+		value interface{}
+		fails bool
+	}{
+		{name: "float", path: "/version", value: float64(1)},
+		{name: "int", path: "/version", value: 1},
+		{name: "decimal", path: "/ratio", value: 0.5},
+		{name: "exponent", path: "/list", value: []interface{}{float64(100), "a"}},
+		{name: "different number", path: "/version", value: float64(2), fails: true},
+		{name: "string", path: "/version", value: "1", fails: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			patch := Patch{
+				{Op: OpTest, Path: tc.path, Value: tc.value},
+			}
+			err := Apply(set.MakeSet(doc), patch)
+			if tc.fails && err == nil {
+				t.Error("expected error")
+			} else if !tc.fails && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestApplyArrays(t *testing.T) {
+	doc := map[string]interface{}{
+		"list": []interface{}{"a", "c"},
+	}
+	patch := Patch{
+		{Op: OpAdd, Path: "/list/1", Value: "b"},
+		{Op: OpAdd, Path: "/list/-", Value: "d"},
+		{Op: OpRemove, Path: "/list/0"},
+	}
+	if err := Apply(set.MakeSet(doc), patch); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []interface{}{"b", "c", "d"}; !reflect.DeepEqual(doc["list"], expected) {
+		t.Errorf("expected %v, got %v", expected, doc["list"])
+	}
+}
+
+func TestOperationJSON(t *testing.T) {
+	patch := Patch{
+		{Op: OpAdd, Path: "/a", Value: ""},
+		{Op: OpRemove, Path: "/b"},
+		{Op: OpMove, From: "/c", Path: "/d"},
+	}
+	b, err := json.Marshal(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"op":"add","path":"/a","value":""},{"op":"remove","path":"/b"},{"from":"/c","op":"move","path":"/d"}]`
+	if string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+
+	var res Patch
+	if err := json.Unmarshal(b, &res); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, patch) {
+		t.Errorf("expected %v, got %v", patch, res)
+	}
+
+	for _, src := range []string{
+		`{"op": "add", "path": "/a"}`,
+		`{"op": "copy", "path": "/a"}`,
+		`{"op": "unknown", "path": "/a"}`,
+		`{"path": "/a"}`,
+	} {
+		var op Operation
+		if err := json.Unmarshal([]byte(src), &op); err == nil {
+			t.Errorf("expected error for %s", src)
+		}
+	}
+}
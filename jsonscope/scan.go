@@ -0,0 +1,184 @@
+package jsonscope
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// The scanner walks over raw JSON, which has already been validated, without
+// decoding the values that are skipped.
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func skipSpace(data []byte, i int) int {
+	for i < len(data) && isSpace(data[i]) {
+		i++
+	}
+	return i
+}
+
+// trim returns the JSON value without any surrounding whitespace.
+func trim(data []byte) []byte {
+	return bytes.TrimFunc(data, func(r rune) bool {
+		return r < 0x80 && isSpace(byte(r))
+	})
+}
+
+// skipValue returns the offset after the value starting at the offset.
+func skipValue(data []byte, i int) (int, error) {
+	if i >= len(data) {
+		return i, errors.Errorf("unexpected end of JSON")
+	}
+	switch data[i] {
+	case '"':
+		return skipString(data, i)
+	case '{', '[':
+		depth := 0
+		for i < len(data) {
+			switch data[i] {
+			case '"':
+				end, err := skipString(data, i)
+				if err != nil {
+					return end, err
+				}
+				i = end
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1, nil
+				}
+			}
+			i++
+		}
+		return i, errors.Errorf("unexpected end of JSON")
+	}
+	// Numbers, bools and null run until the next delimiter.
+	start := i
+	for i < len(data) && !isSpace(data[i]) && data[i] != ',' && data[i] != '}' && data[i] != ']' {
+		i++
+	}
+	if i == start {
+		return i, errors.Errorf("invalid character %q at offset %d", data[i], i)
+	}
+	return i, nil
+}
+
+// skipString returns the offset after the string starting at the offset.
+func skipString(data []byte, i int) (int, error) {
+	for i++; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return i, errors.Errorf("unexpected end of JSON")
+}
+
+// eachMember calls the function with the raw key, including the quotes, and
+// the raw value of each member of an object, until the function returns
+// false.
+func eachMember(data []byte, fn func(key, value []byte) bool) error {
+	i := skipSpace(data, 0)
+	if i >= len(data) || data[i] != '{' {
+		return errors.Errorf("expected a JSON object")
+	}
+	i = skipSpace(data, i+1)
+	if i < len(data) && data[i] == '}' {
+		return nil
+	}
+	for i < len(data) {
+		start := i
+		end, err := skipString(data, i)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		key := data[start:end]
+
+		i = skipSpace(data, end)
+		if i >= len(data) || data[i] != ':' {
+			return errors.Errorf("expected ':' at offset %d", i)
+		}
+		i = skipSpace(data, i+1)
+		start = i
+		if i, err = skipValue(data, i); err != nil {
+			return errors.WithStack(err)
+		}
+		if !fn(key, data[start:i]) {
+			return nil
+		}
+
+		i = skipSpace(data, i)
+		if i < len(data) && data[i] == '}' {
+			return nil
+		}
+		if i >= len(data) || data[i] != ',' {
+			return errors.Errorf("expected ',' at offset %d", i)
+		}
+		i = skipSpace(data, i+1)
+	}
+	return errors.Errorf("unexpected end of JSON")
+}
+
+// eachElement calls the function with the raw value of each element of an
+// array, until the function returns false.
+func eachElement(data []byte, fn func(value []byte) bool) error {
+	i := skipSpace(data, 0)
+	if i >= len(data) || data[i] != '[' {
+		return errors.Errorf("expected a JSON array")
+	}
+	i = skipSpace(data, i+1)
+	if i < len(data) && data[i] == ']' {
+		return nil
+	}
+	for i < len(data) {
+		start := i
+		var err error
+		if i, err = skipValue(data, i); err != nil {
+			return errors.WithStack(err)
+		}
+		if !fn(data[start:i]) {
+			return nil
+		}
+
+		i = skipSpace(data, i)
+		if i < len(data) && data[i] == ']' {
+			return nil
+		}
+		if i >= len(data) || data[i] != ',' {
+			return errors.Errorf("expected ',' at offset %d", i)
+		}
+		i = skipSpace(data, i+1)
+	}
+	return errors.Errorf("unexpected end of JSON")
+}
+
+// unquote returns the text of a raw string. Strings without escapes are
+// returned without decoding them.
+func unquote(raw []byte) (string, error) {
+	if bytes.IndexByte(raw, '\\') < 0 {
+		return string(raw[1 : len(raw)-1]), nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return s, nil
+}
+
+// keyEquals returns if the raw key is the identifier.
+func keyEquals(raw []byte, ident string) bool {
+	if bytes.IndexByte(raw, '\\') < 0 {
+		return string(raw[1:len(raw)-1]) == ident
+	}
+	key, err := unquote(raw)
+	return err == nil && key == ident
+}
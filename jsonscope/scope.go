@@ -0,0 +1,285 @@
+// Package jsonscope queries raw JSON without decoding it first. Only the
+// values a query touches are decoded, the rest are skipped over, so querying
+// a few values of a large document is much cheaper than decoding the whole
+// document into maps.
+//
+//	scope, err := jsonscope.FromBytes(data)
+//	if err != nil {
+//		...
+//	}
+//	result, err := query.Run(scope)
+//
+// Strings are returned as a path.StringScope and everything else as a Value.
+// Numbers keep their precision, as they're compared as integers or as
+// decimals with far more precision than a float64.
+package jsonscope
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+)
+
+// Value defines the type for querying a raw JSON value.
+type Value struct {
+	raw json.RawMessage
+}
+
+// FromBytes creates a scope from raw JSON, which is validated up front so
+// that errors aren't found part way through a query.
+func FromBytes(data []byte) (path.Scope, error) {
+	if !json.Valid(data) {
+		return nil, errors.Errorf("invalid JSON")
+	}
+	return fromRaw(trim(data))
+}
+
+func fromRaw(raw []byte) (path.Scope, error) {
+	if len(raw) > 0 && raw[0] == '"' {
+		s, err := unquote(raw)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return path.MakeStringScope(s), nil
+	}
+	return Value{
+		raw: raw,
+	}, nil
+}
+
+// Raw returns the raw JSON of the value.
+func (s Value) Raw() json.RawMessage {
+	return s.raw
+}
+
+// Interface decodes the value, with numbers decoded as json.Number.
+func (s Value) Interface() (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(s.raw))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return v, nil
+}
+
+func (s Value) kind() byte {
+	if len(s.raw) == 0 {
+		return 0
+	}
+	return s.raw[0]
+}
+
+// GetAllIdents returns all the identifiers for a given scope.
+func (s Value) GetAllIdents() []string {
+	result := make([]string, 0)
+	switch s.kind() {
+	case '{':
+		seen := make(map[string]bool)
+		eachMember(s.raw, func(raw, _ []byte) bool {
+			if key, err := unquote(raw); err == nil && !seen[key] {
+				seen[key] = true
+				result = append(result, key)
+			}
+			return true
+		})
+	case '[':
+		var i int
+		eachElement(s.raw, func([]byte) bool {
+			result = append(result, strconv.Itoa(i))
+			i++
+			return true
+		})
+	}
+	return result
+}
+
+// GetIdentValue returns the value of the identifier in a given scope. As with
+// encoding/json, the last member of an object wins if a key is repeated.
+func (s Value) GetIdentValue(v string) (path.Scope, error) {
+	var value []byte
+	switch s.kind() {
+	case '{':
+		eachMember(s.raw, func(key, raw []byte) bool {
+			if keyEquals(key, v) {
+				value = raw
+			}
+			return true
+		})
+	case '[':
+		index, err := strconv.Atoi(v)
+		if err != nil || index < 0 {
+			break
+		}
+		var i int
+		eachElement(s.raw, func(raw []byte) bool {
+			if i == index {
+				value = raw
+				return false
+			}
+			i++
+			return true
+		})
+	}
+	if value == nil {
+		return nil, path.NotFoundErrorf("no ident value %q found in scope", v)
+	}
+	return fromRaw(value)
+}
+
+// RunOperation attempts to run an operation on a given scope. Objects and
+// arrays return the members that match, everything else is compared with the
+// scope.
+func (s Value) RunOperation(op path.Operation, scope path.Scope) (path.Scope, error) {
+	switch s.kind() {
+	case '{':
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		err := eachMember(s.raw, func(key, raw []byte) bool {
+			if s.matches(op, scope, raw) {
+				if buf.Len() > 1 {
+					buf.WriteByte(',')
+				}
+				buf.Write(key)
+				buf.WriteByte(':')
+				buf.Write(raw)
+			}
+			return true
+		})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		buf.WriteByte('}')
+		return Value{raw: buf.Bytes()}, nil
+
+	case '[':
+		var buf bytes.Buffer
+		buf.WriteByte('[')
+		err := eachElement(s.raw, func(raw []byte) bool {
+			if s.matches(op, scope, raw) {
+				if buf.Len() > 1 {
+					buf.WriteByte(',')
+				}
+				buf.Write(raw)
+			}
+			return true
+		})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		buf.WriteByte(']')
+		return Value{raw: buf.Bytes()}, nil
+	}
+
+	other, err := literal(scope)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var cmp int
+	switch s.kind() {
+	case 't', 'f':
+		b, err := strconv.ParseBool(other)
+		if err != nil {
+			return nil, errors.Errorf("invalid bool %q", other)
+		}
+		if (s.kind() == 't') != b {
+			cmp = 1
+		}
+	case 'n':
+		if other != "null" {
+			cmp = 1
+		}
+	default:
+		if cmp, err = compareNumbers(string(s.raw), other); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	var match bool
+	switch op {
+	case path.OpEQ:
+		match = cmp == 0
+	case path.OpNEQ:
+		match = cmp != 0
+	default:
+		if k := s.kind(); k == 't' || k == 'f' || k == 'n' {
+			return nil, errors.Errorf("unable to order %s values", s.raw)
+		}
+		switch op {
+		case path.OpLT:
+			match = cmp < 0
+		case path.OpLE:
+			match = cmp <= 0
+		case path.OpGT:
+			match = cmp > 0
+		case path.OpGE:
+			match = cmp >= 0
+		}
+	}
+	if !match {
+		return nil, path.NotFoundErrorf("no match")
+	}
+	return s, nil
+}
+
+// matches returns if running the operation of the scope against a member
+// succeeds.
+func (s Value) matches(op path.Operation, scope path.Scope, raw []byte) bool {
+	member, err := fromRaw(raw)
+	if err != nil {
+		return false
+	}
+	_, err = scope.RunOperation(op, member)
+	return err == nil
+}
+
+// literal returns the text of a scope a value is compared with. Queries only
+// have string literals, so the text is converted to the type of the value.
+func literal(scope path.Scope) (string, error) {
+	switch t := scope.(type) {
+	case path.StringScope:
+		return t.Value(), nil
+	case Value:
+		switch t.kind() {
+		case '{', '[':
+		default:
+			return string(t.raw), nil
+		}
+	}
+	return "", errors.Errorf("invalid scope comparision")
+}
+
+// precision is the number of bits numbers are compared with, if they're too
+// large to be compared as integers.
+const precision = 512
+
+// compareNumbers returns -1, 0 or 1 if the number is less than, equal to or
+// greater than the other number.
+func compareNumbers(a, b string) (int, error) {
+	x, errX := strconv.ParseInt(a, 10, 64)
+	y, errY := strconv.ParseInt(b, 10, 64)
+	if errX == nil && errY == nil {
+		switch {
+		case x < y:
+			return -1, nil
+		case x > y:
+			return 1, nil
+		}
+		return 0, nil
+	}
+
+	f, _, err := big.ParseFloat(a, 10, precision, big.ToNearestEven)
+	if err != nil {
+		return 0, errors.Errorf("invalid number %q", a)
+	}
+	g, _, err := big.ParseFloat(b, 10, precision, big.ToNearestEven)
+	if err != nil {
+		return 0, errors.Errorf("invalid number %q", b)
+	}
+	return f.Cmp(g), nil
+}
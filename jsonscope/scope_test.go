@@ -0,0 +1,225 @@
+package jsonscope
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+	"github.com/spoke-d/path/set"
+)
+
+const testDocument = ` {
+	"company": {
+		"person": {"name": "fred", "age": 42, "admin": true, "manager": null},
+		"address": "1 main street",
+		"ids": [12345678901234567890, 1.5, -3e2],
+		"tags": ["a", "b", {"c": "d"}],
+		"escaped \"key\"": "xA\n",
+		"dup": "first",
+		"dup": "last",
+		"empty": {},
+		"none": []
+	}
+} `
+
+func testScope(t testing.TB) path.Scope {
+	scope, err := FromBytes([]byte(testDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return scope
+}
+
+func TestGetAllIdents(t *testing.T) {
+	company, err := testScope(t).GetIdentValue("company")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"person", "address", "ids", "tags", `escaped "key"`, "dup", "empty", "none"}
+	if idents := company.GetAllIdents(); !reflect.DeepEqual(idents, expected) {
+		t.Errorf("expected %v, got %v", expected, idents)
+	}
+
+	tags, err := company.GetIdentValue("tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idents, expected := tags.GetAllIdents(), []string{"0", "1", "2"}; !reflect.DeepEqual(idents, expected) {
+		t.Errorf("expected %v, got %v", expected, idents)
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected interface{}
+	}{
+		{query: `company.person.name`, expected: "fred"},
+		{query: `company.address`, expected: "1 main street"},
+		{query: `company["escaped \"key\""]`, expected: "xA\n"},
+		{query: `company.dup`, expected: "last"},
+		{query: `company.tags["2"].c`, expected: "d"},
+		{query: `company.ids["0"]`, expected: json.Number("12345678901234567890")},
+		{query: `company.person`, expected: map[string]interface{}{
+			"name": "fred", "age": json.Number("42"), "admin": true, "manager": nil,
+		}},
+		{query: `company.empty`, expected: map[string]interface{}{}},
+		{query: `company.none`, expected: []interface{}{}},
+		{query: `company.person.(age == "42")`, expected: json.Number("42")},
+		{query: `company.person.(age >= "41.9")`, expected: json.Number("42")},
+		{query: `company.person.(admin == "true")`, expected: true},
+		{query: `company.person.(manager == "null")`, expected: nil},
+		{query: `company.ids.(["0"] == "12345678901234567890")`, expected: json.Number("12345678901234567890")},
+		{query: `company.ids.(["0"] > "12345678901234567889")`, expected: json.Number("12345678901234567890")},
+		{query: `company.ids.(["2"] < "-299.5")`, expected: json.Number("-3e2")},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := path.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := query.First(testScope(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var value interface{}
+			switch s := result.(type) {
+			case path.StringScope:
+				value = s.Value()
+			case Value:
+				if value, err = s.Interface(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !reflect.DeepEqual(value, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, value)
+			}
+		})
+	}
+}
+
+func TestRunNoMatch(t *testing.T) {
+	tests := []string{
+		`missing`,
+		`company.tags.c`,
+		`company.ids.(["0"] == "12345678901234567891")`,
+		`company.person.(age < "42")`,
+		`company.person.(admin == "false")`,
+		`company.person.(admin < "true")`,
+		`company.person.(age == "old")`,
+		`company.person.(manager != "null")`,
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			query, err := path.Parse(test)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := query.Run(testScope(t)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+// A value that isn't found is ErrNotFound, so that || falls through to the
+// next operand, whereas any other error isn't.
+func TestRunNotFound(t *testing.T) {
+	for _, test := range []struct {
+		query    string
+		notFound bool
+	}{
+		{query: `missing`, notFound: true},
+		{query: `company.tags.c`, notFound: true},
+		{query: `company.person.(age < "42")`, notFound: true},
+		{query: `company.person.(admin == "false")`, notFound: true},
+		{query: `company.person.(admin < "true")`},
+		{query: `company.person.(age == "old")`},
+	} {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := path.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = query.Run(testScope(t))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if notFound := errors.Cause(err) == path.ErrNotFound; notFound != test.notFound {
+				t.Errorf("expected not found to be %t, got %v", test.notFound, err)
+			}
+		})
+	}
+
+	query, err := path.Parse(`company.nickname || company.address`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := query.First(testScope(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := path.MakeStringScope("1 main street"); result != expected {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
+
+func TestRunOperationFilters(t *testing.T) {
+	scope, err := FromBytes([]byte(`{"a": 1, "b": 2, "c": "2"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	two, err := FromBytes([]byte(`2`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := scope.RunOperation(path.OpEQ, two)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw := string(result.(Value).Raw()); raw != `{"b":2,"c":"2"}` {
+		t.Errorf("expected filtered object, got %s", raw)
+	}
+}
+
+func TestFromBytesInvalid(t *testing.T) {
+	if _, err := FromBytes([]byte(`{"a": `)); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+func BenchmarkRun(b *testing.B) {
+	query, err := path.Parse(`company.person.(age > "30")`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := []byte(testDocument)
+
+	b.Run("jsonscope", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			scope, err := FromBytes(data)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := query.Run(scope); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("set", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var m map[string]interface{}
+			if err := json.Unmarshal(data, &m); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := query.Run(set.MakeSet(m)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,322 @@
+package jsonscope
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+)
+
+// NotStreamable is returned when a query can't be run over a stream, as it
+// needs more of the document than the value being read.
+type NotStreamable struct {
+	Pos     path.Position
+	Message string
+}
+
+func (e NotStreamable) Error() string {
+	return fmt.Sprintf("Stream Error:%v %s", e.Pos, e.Message)
+}
+
+// IsNotStreamable returns if the error is because a query can't be run over a
+// stream.
+func IsNotStreamable(err error) bool {
+	_, ok := errors.Cause(err).(NotStreamable)
+	return ok
+}
+
+// Stream runs the query over the JSON documents read from the reader, calling
+// the function with each result as soon as it's read. Only the results are
+// held in memory, so documents of any size can be queried. Returning an error
+// from the function stops the stream and the error is returned.
+//
+// Only queries of a single statement that walk down the document can be
+// streamed: identifiers, strings, indexes and descents, optionally ending with
+// a comparison against a string literal. Unlike Run, the right of a
+// comparison is always a literal, as looking it up would need the rest of the
+// object. An identifier that isn't found has no results rather than an
+// error, whilst a string that isn't found is used as a literal, as with Run.
+//
+//	query, _ := path.Parse(`..`)
+//	err := jsonscope.Stream(r, query, func(scope path.Scope) error {
+//		...
+//	})
+func Stream(r io.Reader, query path.Path, fn func(path.Scope) error) error {
+	plan, err := compileStream(query.AST())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	s := &streamer{
+		decoder: decoder,
+		plan:    plan,
+		fn:      fn,
+	}
+	for decoder.More() {
+		if err := s.value(0); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	// Anything left over that isn't a value is invalid.
+	if _, err := decoder.Token(); err != io.EOF {
+		if err == nil {
+			return errors.Errorf("invalid JSON at offset %d", decoder.InputOffset())
+		}
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// step is a single level of the document that a streamed query walks down.
+// As with Run, a string is used as a literal if there is no value for it.
+type step struct {
+	key     string
+	any     bool
+	literal bool
+}
+
+func (s step) matches(key string) bool {
+	return s.any || s.key == key
+}
+
+// filter is a comparison of the result against a literal.
+type filter struct {
+	op      path.Operation
+	literal path.Scope
+}
+
+type streamPlan struct {
+	steps  []step
+	filter *filter
+}
+
+func compileStream(query *path.QueryExpression) (streamPlan, error) {
+	if len(query.Expressions) != 1 {
+		return streamPlan{}, NotStreamable{
+			Pos:     query.Pos(),
+			Message: "only a query of a single statement can be streamed",
+		}
+	}
+	return compileStreamExpression(query.Expressions[0])
+}
+
+func compileStreamExpression(e path.Expression) (streamPlan, error) {
+	switch node := e.(type) {
+	case *path.ExpressionStatement:
+		return compileStreamExpression(node.Expression)
+
+	case *path.Identifier:
+		return streamPlan{steps: []step{{key: node.Token.Literal}}}, nil
+
+	case *path.String:
+		return streamPlan{steps: []step{{key: node.Token.Literal, literal: true}}}, nil
+
+	case *path.DescentExpression:
+		// As with Run, a descent returns all the values of the scope.
+		return streamPlan{steps: []step{{any: true}}}, nil
+
+	case *path.AccessExpression:
+		return compileStreamExpression(node.Index)
+
+	case *path.AccessorExpression:
+		return compileStreamPair(node.Left, node.Right)
+
+	case *path.IndexExpression:
+		return compileStreamPair(node.Left, node.Index)
+
+	case *path.InfixExpression:
+		var op path.Operation
+		switch node.Token.Type {
+		case path.EQ:
+			op = path.OpEQ
+		case path.NEQ:
+			op = path.OpNEQ
+		case path.LT:
+			op = path.OpLT
+		case path.LE:
+			op = path.OpLE
+		case path.GT:
+			op = path.OpGT
+		case path.GE:
+			op = path.OpGE
+		default:
+			return streamPlan{}, NotStreamable{
+				Pos:     node.Pos(),
+				Message: fmt.Sprintf("the %s operator can't be streamed, as it needs the rest of the object", node.Operator),
+			}
+		}
+		literal, ok := node.Right.(*path.String)
+		if !ok {
+			return streamPlan{}, NotStreamable{
+				Pos:     node.Pos(),
+				Message: "only comparisons with a string literal can be streamed",
+			}
+		}
+		plan, err := compileStreamExpression(node.Left)
+		if err != nil {
+			return streamPlan{}, err
+		}
+		if plan.filter != nil {
+			return streamPlan{}, NotStreamable{
+				Pos:     node.Pos(),
+				Message: "a comparison of a comparison can't be streamed",
+			}
+		}
+		plan.filter = &filter{
+			op:      op,
+			literal: path.MakeStringScope(literal.Token.Literal),
+		}
+		return plan, nil
+	}
+
+	pos := path.Position{}
+	if e != nil {
+		pos = e.Pos()
+	}
+	return streamPlan{}, NotStreamable{
+		Pos:     pos,
+		Message: fmt.Sprintf("%T can't be streamed", e),
+	}
+}
+
+// compileStreamPair compiles an expression run against the result of another
+// expression.
+func compileStreamPair(left, right path.Expression) (streamPlan, error) {
+	l, err := compileStreamExpression(left)
+	if err != nil {
+		return streamPlan{}, err
+	}
+	if l.filter != nil {
+		return streamPlan{}, NotStreamable{
+			Pos:     right.Pos(),
+			Message: "only a comparison at the end of a query can be streamed",
+		}
+	}
+	r, err := compileStreamExpression(right)
+	if err != nil {
+		return streamPlan{}, err
+	}
+	return streamPlan{
+		steps:  append(l.steps, r.steps...),
+		filter: r.filter,
+	}, nil
+}
+
+type streamer struct {
+	decoder *json.Decoder
+	plan    streamPlan
+	fn      func(path.Scope) error
+}
+
+// value reads the next value of the stream, which is at the depth of the
+// steps of the query.
+func (s *streamer) value(depth int) error {
+	if depth == len(s.plan.steps) {
+		var raw json.RawMessage
+		if err := s.decoder.Decode(&raw); err != nil {
+			return errors.WithStack(err)
+		}
+		scope, err := fromRaw(raw)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return s.emit(scope)
+	}
+
+	token, err := s.decoder.Token()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		var (
+			index int
+			found bool
+		)
+		for s.decoder.More() {
+			key := strconv.Itoa(index)
+			if t == '{' {
+				if token, err = s.decoder.Token(); err != nil {
+					return errors.WithStack(err)
+				}
+				key = token.(string)
+			}
+			index++
+
+			if s.plan.steps[depth].matches(key) {
+				found = true
+				err = s.value(depth + 1)
+			} else {
+				err = s.skip()
+			}
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		// The closing delimiter.
+		if _, err := s.decoder.Token(); err != nil {
+			return errors.WithStack(err)
+		}
+		if !found && s.plan.steps[depth].literal {
+			return s.string(path.MakeStringScope(s.plan.steps[depth].key), depth+1)
+		}
+
+	case string:
+		return s.string(path.MakeStringScope(t), depth)
+
+	default:
+		if s.plan.steps[depth].literal {
+			return s.string(path.MakeStringScope(s.plan.steps[depth].key), depth+1)
+		}
+	}
+	return nil
+}
+
+// string emits a string for the rest of the steps from the depth. A string
+// returns itself for every identifier, but has no values to descend into.
+func (s *streamer) string(scope path.StringScope, depth int) error {
+	for _, step := range s.plan.steps[depth:] {
+		if step.any {
+			return nil
+		}
+	}
+	return s.emit(scope)
+}
+
+// skip reads the next value of the stream without keeping it.
+func (s *streamer) skip() error {
+	var depth int
+	for {
+		token, err := s.decoder.Token()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if delim, ok := token.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				depth++
+			default:
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func (s *streamer) emit(scope path.Scope) error {
+	if f := s.plan.filter; f != nil {
+		result, err := scope.RunOperation(f.op, f.literal)
+		if err != nil {
+			return nil
+		}
+		scope = result
+	}
+	return s.fn(scope)
+}
@@ -0,0 +1,187 @@
+package jsonscope
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+)
+
+func scopeValue(t *testing.T, scope path.Scope) interface{} {
+	switch s := scope.(type) {
+	case path.StringScope:
+		return s.Value()
+	case Value:
+		v, err := s.Interface()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	t.Fatalf("unexpected scope %T", scope)
+	return nil
+}
+
+func TestStream(t *testing.T) {
+	tests := []string{
+		`company`,
+		`company.person.name`,
+		`company["person"].age`,
+		`company.person.["admin"]`,
+		`company..`,
+		`company.tags..`,
+		`company.tags["2"].c`,
+		`company.address.anything`,
+		`company.tags["9"]`,
+		`company.tags["9"].x`,
+		`company.person.age["x"]`,
+		`company["missing"].(x == "missing")`,
+		`company.person.(name == "fred")`,
+		`company.person.(age > "40")`,
+		`company.person.(age < "40")`,
+		`company.ids.(["0"] == "12345678901234567890")`,
+		`company.(person == "x")`,
+		`missing`,
+		`company.tags.x`,
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			query, err := path.Parse(test)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Streaming has the same results as iterating over the whole
+			// document, apart from repeated keys which are all streamed.
+			document := strings.Replace(testDocument, `"dup": "first",`, "", 1)
+			scope, err := FromBytes([]byte(document))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var expected []interface{}
+			iter := query.Iter(scope)
+			for {
+				result, ok := iter.Next()
+				if !ok {
+					break
+				}
+				expected = append(expected, scopeValue(t, result))
+			}
+
+			var results []interface{}
+			if err := Stream(strings.NewReader(document), query, func(scope path.Scope) error {
+				results = append(results, scopeValue(t, scope))
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(results, expected) {
+				t.Errorf("expected %#v, got %#v", expected, results)
+			}
+		})
+	}
+}
+
+func TestStreamRepeatedKeys(t *testing.T) {
+	query, err := path.Parse(`company.dup`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []interface{}
+	if err := Stream(strings.NewReader(testDocument), query, func(scope path.Scope) error {
+		results = append(results, scopeValue(t, scope))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []interface{}{"first", "last"}; !reflect.DeepEqual(results, expected) {
+		t.Errorf("expected %#v, got %#v", expected, results)
+	}
+}
+
+func TestStreamDocuments(t *testing.T) {
+	query, err := path.Parse(`..`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := `[{"id": "1"}, {"id": "2"}] {"a": "3"} "4"`
+
+	var results []interface{}
+	if err := Stream(strings.NewReader(input), query, func(scope path.Scope) error {
+		results = append(results, scopeValue(t, scope))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{
+		map[string]interface{}{"id": "1"},
+		map[string]interface{}{"id": "2"},
+		"3",
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("expected %#v, got %#v", expected, results)
+	}
+}
+
+func TestStreamStop(t *testing.T) {
+	query, err := path.Parse(`..`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := errors.New("stop")
+	var num int
+	err = Stream(strings.NewReader(`["a", "b", "c"]`), query, func(path.Scope) error {
+		num++
+		return stop
+	})
+	if errors.Cause(err) != stop || num != 1 {
+		t.Errorf("expected to stop after 1 result, got %d: %v", num, err)
+	}
+}
+
+func TestStreamInvalid(t *testing.T) {
+	query, err := path.Parse(`a.b`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range []string{`{"a": {"b": `, `{"a": "x"} ]`} {
+		if err := Stream(strings.NewReader(input), query, func(path.Scope) error {
+			return nil
+		}); err == nil || IsNotStreamable(err) {
+			t.Errorf("expected a JSON error for %q, got %v", input, err)
+		}
+	}
+}
+
+func TestStreamNotStreamable(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: `a; b`, expected: "Stream Error:<:1:1> only a query of a single statement can be streamed"},
+		{query: `a.(b == "1" && c == "2")`, expected: "Stream Error:<:1:13> the && operator can't be streamed, as it needs the rest of the object"},
+		{query: `a || b`, expected: "Stream Error:<:1:3> the || operator can't be streamed, as it needs the rest of the object"},
+		{query: `a.(b == c)`, expected: "Stream Error:<:1:6> only comparisons with a string literal can be streamed"},
+		{query: `a.(b == "1").c`, expected: "Stream Error:<:1:14> only a comparison at the end of a query can be streamed"},
+		{query: `((a == "1") == "2")`, expected: "Stream Error:<:1:13> a comparison of a comparison can't be streamed"},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := path.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = Stream(strings.NewReader(`{}`), query, func(path.Scope) error {
+				return nil
+			})
+			if !IsNotStreamable(err) {
+				t.Fatalf("expected not streamable, got %v", err)
+			}
+			if err.Error() != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, err.Error())
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package path
 
 import (
+	"fmt"
 	"strings"
 	"text/scanner"
 	"unicode"
+	"unicode/utf16"
 	"unicode/utf8"
 )
 
@@ -11,29 +13,52 @@ import (
 // at later date.
 // The lexer in question is lazy and requires the calling of next to move it
 // forward.
+//
+// Spaces, tabs, carriage returns and newlines are all treated as whitespace
+// and are skipped between tokens, so a query can be spread over as many lines
+// as required. Comments are emitted as COMMENT tokens and come in the
+// following forms:
+//
+//	# a line comment, up until the end of the line
+//	// a line comment, up until the end of the line
+//	/* a block comment, which can span multiple lines */
 type Lexer struct {
 	input   string
 	scanner scanner.Scanner
+	tok     rune
 	text    string
 	isEOF   bool
+	errors  ParseErrors
 }
 
 // NewLexer creates a new Lexer from a given input.
 func NewLexer(input string) *Lexer {
-	var scanner scanner.Scanner
-	scanner.Init(strings.NewReader(input))
 	lex := &Lexer{
-		input:   input,
-		scanner: scanner,
+		input: input,
+	}
+	lex.scanner.Init(strings.NewReader(input))
+	// Double and single quoted strings are read by the lexer itself, so that
+	// both quote styles share the same escape handling.
+	// Comments are not skipped, so that they can be kept along side the AST.
+	lex.scanner.Mode = scanner.GoTokens &^ (scanner.ScanStrings | scanner.ScanChars | scanner.SkipComments)
+	lex.scanner.Whitespace = whitespace
+	lex.scanner.IsIdentRune = isIdentRune
+	lex.scanner.Error = func(s *scanner.Scanner, msg string) {
+		lex.errorf(s.Pos(), msg)
 	}
 	lex.ReadNext()
 	return lex
 }
 
+// Errors returns any errors found whilst reading the input.
+func (l *Lexer) Errors() ParseErrors {
+	return l.errors
+}
+
 // ReadNext will attempt to read the next character and correctly setup the
 // positional values for the input.
 func (l *Lexer) ReadNext() {
-	if l.scanner.Scan() == scanner.EOF {
+	if l.tok = l.scanner.Scan(); l.tok == scanner.EOF {
 		l.isEOF = true
 		l.text = ""
 		return
@@ -43,13 +68,21 @@ func (l *Lexer) ReadNext() {
 
 // Peek will attempt to read the next rune if it's available.
 func (l *Lexer) Peek() rune {
-	return l.PeekN(1)
+	return l.scanner.Peek()
 }
 
 // PeekN attempts to read the next rune by a given offset, it it's available.
+// If the offset is past the end of the input, then EOF is returned.
 func (l *Lexer) PeekN(n int) rune {
-	pos := l.scanner.Position
-	return rune(l.input[pos.Offset+n])
+	offset := l.scanner.Pos().Offset
+	for i := 1; offset < len(l.input); i++ {
+		r, size := utf8.DecodeRuneInString(l.input[offset:])
+		if i == n {
+			return r
+		}
+		offset += size
+	}
+	return scanner.EOF
 }
 
 // NextToken attempts to grab the next token available.
@@ -58,7 +91,6 @@ func (l *Lexer) NextToken() Token {
 
 	var tok Token
 	pos := l.getPosition()
-	pos.Column--
 
 	if t, ok := tokenMap[l.text]; ok {
 		switch t {
@@ -140,21 +172,230 @@ func (l *Lexer) readRunesToken() Token {
 	case l.isEOF:
 		tok.Type = EOF
 		return tok
-	case len(l.text) > 0 && isLetter(l.text[0]):
-		tok.Type = IDENT
+	case l.tok == scanner.Comment:
+		tok.Type = COMMENT
 		tok.Literal = l.text
 		return tok
+	case l.text == "#":
+		tok.Type = COMMENT
+		tok.Literal = l.readLineComment()
+		return tok
+	case l.tok == scanner.Ident:
+		tok.Type = IDENT
+		tok.Literal, tok.Raw = l.readIdent(l.text)
+		return tok
+	case l.text == "\\":
+		tok.Type = IDENT
+		tok.Literal, tok.Raw = l.readIdent("")
+		return tok
 	case len(l.text) > 0 && isQuote(l.text[0]):
 		tok.Type = STRING
-		tok.Literal = l.text[1 : len(l.text)-1]
+		tok.Literal, tok.Raw = l.readQuoted(rune(l.text[0]))
+		return tok
+	case len(l.text) > 0 && isRawQuote(l.text[0]):
+		tok.Type = STRING
+		tok.Raw = l.text
+		// Carriage returns are discarded from raw strings, the same as Go.
+		tok.Literal = strings.Replace(strings.TrimSuffix(l.text[1:], "`"), "\r", "", -1)
 		return tok
 	}
 
 	return MakeToken(UNKNOWN, l.text)
 }
 
+// readIdent reads the rest of an identifier, including any escaped runes. Any
+// rune can be escaped using a backslash, which allows identifiers to contain
+// runes that would otherwise end the identifier (app\.kubernetes\.io\/name).
+// The unescaped value and the raw source are returned.
+func (l *Lexer) readIdent(text string) (string, string) {
+	var (
+		value strings.Builder
+		raw   strings.Builder
+	)
+	value.WriteString(text)
+	raw.WriteString(text)
+	if text == "" {
+		// The identifier starts with an escaped rune.
+		l.readIdentEscape(&value, &raw)
+	}
+	for i := utf8.RuneCountInString(value.String()); ; i++ {
+		ch := l.scanner.Peek()
+		switch {
+		case ch == '\\':
+			l.scanner.Next()
+			l.readIdentEscape(&value, &raw)
+		case isIdentRune(ch, i):
+			l.scanner.Next()
+			value.WriteRune(ch)
+			raw.WriteRune(ch)
+		default:
+			return value.String(), raw.String()
+		}
+	}
+}
+
+// readIdentEscape reads the rune after a backslash, the backslash has already
+// been consumed.
+func (l *Lexer) readIdentEscape(value, raw *strings.Builder) {
+	raw.WriteByte('\\')
+	if ch := l.scanner.Peek(); ch == scanner.EOF || ch == '\n' {
+		l.errorf(l.scanner.Pos(), "escape sequence not terminated")
+		return
+	}
+	ch := l.scanner.Next()
+	value.WriteRune(ch)
+	raw.WriteRune(ch)
+}
+
+// readLineComment reads the rest of the current line, not including the
+// newline itself.
+func (l *Lexer) readLineComment() string {
+	var comment strings.Builder
+	comment.WriteString(l.text)
+	for {
+		if ch := l.scanner.Peek(); ch == scanner.EOF || ch == '\n' {
+			return strings.TrimSuffix(comment.String(), "\r")
+		}
+		comment.WriteRune(l.scanner.Next())
+	}
+}
+
+// readQuoted reads a quoted string up to the closing quote, decoding any
+// escape sequences along the way. The decoded value and the raw source are
+// returned.
+func (l *Lexer) readQuoted(quote rune) (string, string) {
+	var (
+		value strings.Builder
+		raw   strings.Builder
+	)
+	raw.WriteRune(quote)
+	for {
+		pos := l.scanner.Pos()
+		ch := l.scanner.Next()
+		switch ch {
+		case scanner.EOF, '\n':
+			l.errorf(pos, "string literal not terminated")
+			return value.String(), raw.String()
+		}
+		raw.WriteRune(ch)
+		switch ch {
+		case quote:
+			return value.String(), raw.String()
+		case '\\':
+			l.readEscape(pos, &value, &raw)
+		default:
+			value.WriteRune(ch)
+		}
+	}
+}
+
+// readEscape decodes a single escape sequence, the backslash has already been
+// consumed. Both Go and JSON escape sequences are supported.
+func (l *Lexer) readEscape(pos scanner.Position, value, raw *strings.Builder) {
+	ch := l.scanner.Peek()
+	if ch == scanner.EOF || ch == '\n' {
+		return
+	}
+	raw.WriteRune(l.scanner.Next())
+
+	switch ch {
+	case 'a':
+		value.WriteByte('\a')
+	case 'b':
+		value.WriteByte('\b')
+	case 'f':
+		value.WriteByte('\f')
+	case 'n':
+		value.WriteByte('\n')
+	case 'r':
+		value.WriteByte('\r')
+	case 't':
+		value.WriteByte('\t')
+	case 'v':
+		value.WriteByte('\v')
+	case '\\', '/', '"', '\'':
+		value.WriteRune(ch)
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		n, ok := l.readDigits(ch, 8, 2, raw)
+		if !ok || n > 255 {
+			l.errorf(pos, "invalid octal escape sequence")
+			return
+		}
+		value.WriteByte(byte(n))
+	case 'x':
+		n, ok := l.readDigits(-1, 16, 2, raw)
+		if !ok {
+			l.errorf(pos, "invalid hex escape sequence")
+			return
+		}
+		value.WriteByte(byte(n))
+	case 'u', 'U':
+		size := 4
+		if ch == 'U' {
+			size = 8
+		}
+		n, ok := l.readDigits(-1, 16, size, raw)
+		if !ok {
+			l.errorf(pos, "invalid unicode escape sequence")
+			return
+		}
+		r := rune(n)
+		// JSON encodes runes outside of the basic multilingual plane as a
+		// UTF-16 surrogate pair.
+		if utf16.IsSurrogate(r) && l.scanner.Peek() == '\\' && l.PeekN(2) == 'u' {
+			raw.WriteRune(l.scanner.Next())
+			raw.WriteRune(l.scanner.Next())
+			low, ok := l.readDigits(-1, 16, 4, raw)
+			if !ok {
+				l.errorf(pos, "invalid unicode escape sequence")
+				return
+			}
+			r = utf16.DecodeRune(r, rune(low))
+		}
+		if !utf8.ValidRune(r) {
+			l.errorf(pos, "escape sequence is invalid unicode code point")
+			return
+		}
+		value.WriteRune(r)
+	default:
+		l.errorf(pos, "unknown escape sequence")
+	}
+}
+
+// readDigits reads num digits in the given base. If first is not negative it
+// is used as the first digit, which has already been consumed.
+func (l *Lexer) readDigits(first rune, base, num int, raw *strings.Builder) (int, bool) {
+	var n int
+	if first >= 0 {
+		n = digitVal(first)
+	}
+	for i := 0; i < num; i++ {
+		d := digitVal(l.scanner.Peek())
+		if d >= base {
+			return n, false
+		}
+		raw.WriteRune(l.scanner.Next())
+		n = n*base + d
+	}
+	return n, true
+}
+
+func (l *Lexer) errorf(pos scanner.Position, msg string, args ...interface{}) {
+	p := Position{
+		Offset: pos.Offset,
+		Line:   pos.Line,
+		Column: pos.Column,
+	}
+	l.errors = append(l.errors, &ParseError{
+		Pos: p,
+		Msg: fmt.Sprintf(msg, args...),
+	})
+}
+
 func (l *Lexer) getPosition() Position {
-	pos := l.scanner.Pos()
+	// The scanner position holds the start of the most recently scanned
+	// token, which is the current token.
+	pos := l.scanner.Position
 	return Position{
 		Offset: pos.Offset,
 		Line:   pos.Line,
@@ -162,10 +403,47 @@ func (l *Lexer) getPosition() Position {
 	}
 }
 
-func isLetter(char byte) bool {
-	return 'a' <= char && char <= 'z' || 'A' <= char && char <= 'Z' || char == '_' || char >= utf8.RuneSelf && unicode.IsLetter(rune(char))
+// whitespace defines the characters that are skipped between tokens.
+const whitespace = 1<<'\t' | 1<<'\n' | 1<<'\r' | 1<<' '
+
+// isIdentRune reports whether the rune can be the ith rune of an
+// identifier. Identifiers start with a unicode letter or an underscore, which
+// can then be followed by letters, marks, digits, underscores or dashes.
+func isIdentRune(ch rune, i int) bool {
+	return ch == '_' || unicode.IsLetter(ch) || i > 0 && (ch == '-' || unicode.IsDigit(ch) || unicode.IsMark(ch))
+}
+
+// QuoteIdent returns the identifier escaping any runes that are not valid
+// identifier runes, so that it can be read back by the lexer.
+func QuoteIdent(ident string) string {
+	var out strings.Builder
+	var i int
+	for _, ch := range ident {
+		if !isIdentRune(ch, i) {
+			out.WriteByte('\\')
+		}
+		out.WriteRune(ch)
+		i++
+	}
+	return out.String()
 }
 
 func isQuote(char byte) bool {
-	return char == 34
+	return char == '"' || char == '\''
+}
+
+func isRawQuote(char byte) bool {
+	return char == '`'
+}
+
+func digitVal(ch rune) int {
+	switch {
+	case '0' <= ch && ch <= '9':
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return int(ch - 'a' + 10)
+	case 'A' <= ch && ch <= 'F':
+		return int(ch - 'A' + 10)
+	}
+	return 16 // larger than any legal digit val
 }
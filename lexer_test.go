@@ -0,0 +1,178 @@
+package path
+
+import (
+	"testing"
+)
+
+func TestLexerStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: `"abc"`, expected: "abc"},
+		{input: `'abc'`, expected: "abc"},
+		{input: "`a\\nb`", expected: `a\nb`},
+		{input: `"a\"b"`, expected: `a"b`},
+		{input: `'a\'b'`, expected: `a'b`},
+		{input: `'a"b'`, expected: `a"b`},
+		{input: `"a\nb\tc"`, expected: "a\nb\tc"},
+		{input: `"a\/b"`, expected: "a/b"},
+		{input: `"é"`, expected: "é"},
+		{input: `"\U0001F600"`, expected: "\U0001F600"},
+		{input: `"😀"`, expected: "\U0001F600"},
+		{input: `"\x41\101"`, expected: "AA"},
+		{input: `"key with spaces"`, expected: "key with spaces"},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			lex := NewLexer(test.input)
+			tok := lex.NextToken()
+			if tok.Type != STRING {
+				t.Fatalf("expected %s, got %s", STRING, tok.Type)
+			}
+			if tok.Literal != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, tok.Literal)
+			}
+			if tok.Raw != test.input {
+				t.Errorf("expected raw %q, got %q", test.input, tok.Raw)
+			}
+			if errs := lex.Errors(); len(errs) > 0 {
+				t.Errorf("unexpected errors %v", errs)
+			}
+		})
+	}
+}
+
+func TestLexerStringErrors(t *testing.T) {
+	tests := []string{
+		`"abc`,
+		`'abc`,
+		"`abc",
+		`"a\qb"`,
+		`"\u00"`,
+		`"\400"`,
+	}
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			if _, err := Parse(input); err == nil {
+				t.Errorf("expected error for %s", input)
+			}
+		})
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	tests := []string{
+		`aaa["key with spaces"]`,
+		`aaa["a\"b"]`,
+		`aaa["é\n"]`,
+	}
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			query, err := Parse(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			str := query.ast.String()
+			again, err := Parse(str)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again.ast.String() != str {
+				t.Errorf("expected %s, got %s", str, again.ast.String())
+			}
+		})
+	}
+}
+
+func TestLexerPositions(t *testing.T) {
+	lex := NewLexer("aaa\n  .bbb == 'x' # comment\n\t[\"c\"]")
+	expected := []struct {
+		tokenType TokenType
+		line      int
+		column    int
+	}{
+		{IDENT, 1, 1},
+		{PERIOD, 2, 3},
+		{IDENT, 2, 4},
+		{EQ, 2, 8},
+		{STRING, 2, 11},
+		{COMMENT, 2, 15},
+		{LBRACKET, 3, 2},
+		{STRING, 3, 3},
+		{RBRACKET, 3, 6},
+		{EOF, 3, 7},
+	}
+	for _, e := range expected {
+		tok := lex.NextToken()
+		if tok.Type != e.tokenType {
+			t.Fatalf("expected %s, got %s", e.tokenType, tok.Type)
+		}
+		if tok.Pos.Line != e.line || tok.Pos.Column != e.column {
+			t.Errorf("%s: expected <:%d:%d>, got %v", tok.Type, e.line, e.column, tok.Pos)
+		}
+	}
+}
+
+func TestLexerComments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "# abc\n", expected: "# abc"},
+		{input: "// abc\n", expected: "// abc"},
+		{input: "/* a\nb */", expected: "/* a\nb */"},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			tok := NewLexer(test.input).NextToken()
+			if tok.Type != COMMENT {
+				t.Fatalf("expected %s, got %s", COMMENT, tok.Type)
+			}
+			if tok.Literal != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, tok.Literal)
+			}
+		})
+	}
+}
+
+func TestLexerIdentifiers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: `abc`, expected: "abc"},
+		{input: `名前`, expected: "名前"},
+		{input: `café`, expected: "café"},
+		{input: "cafe\u0301", expected: "cafe\u0301"},
+		{input: `_a1`, expected: "_a1"},
+		{input: `app-name`, expected: "app-name"},
+		{input: `app\.kubernetes\.io\/name`, expected: "app.kubernetes.io/name"},
+		{input: `\1abc`, expected: "1abc"},
+		{input: `a\ b`, expected: "a b"},
+		{input: `a\\b`, expected: `a\b`},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			lex := NewLexer(test.input)
+			tok := lex.NextToken()
+			if tok.Type != IDENT {
+				t.Fatalf("expected %s, got %s", IDENT, tok.Type)
+			}
+			if tok.Literal != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, tok.Literal)
+			}
+			if tok.Raw != test.input {
+				t.Errorf("expected raw %q, got %q", test.input, tok.Raw)
+			}
+			if next := lex.NextToken(); next.Type != EOF {
+				t.Errorf("expected %s, got %s", EOF, next.Type)
+			}
+
+			ident := &Identifier{Token: tok}
+			if again := NewLexer(ident.String()).NextToken(); again.Literal != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, again.Literal)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package lsp
+
+import (
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// document is a text document opened by the client.
+type document struct {
+	text  string
+	lines []int
+}
+
+func newDocument(text string) *document {
+	lines := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return &document{
+		text:  text,
+		lines: lines,
+	}
+}
+
+// position returns the LSP position of a byte offset, which counts the
+// characters of a line in UTF-16 code units.
+func (d *document) position(offset int) Position {
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+	line := sort.Search(len(d.lines), func(i int) bool {
+		return d.lines[i] > offset
+	}) - 1
+
+	var character int
+	for _, r := range d.text[d.lines[line]:offset] {
+		character += utf16Len(r)
+	}
+	return Position{
+		Line:      line,
+		Character: character,
+	}
+}
+
+// offset returns the byte offset of an LSP position. Positions past the end
+// of a line are clamped to the end of the line.
+func (d *document) offset(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+	offset := d.lines[pos.Line]
+	for character := 0; character < pos.Character && offset < len(d.text); {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		if r == '\n' {
+			break
+		}
+		character += utf16Len(r)
+		offset += size
+	}
+	return offset
+}
+
+// span returns the range between two byte offsets.
+func (d *document) span(start, end int) Range {
+	return Range{
+		Start: d.position(start),
+		End:   d.position(end),
+	}
+}
+
+func utf16Len(r rune) int {
+	if n := utf16.RuneLen(r); n > 0 {
+		return n
+	}
+	return 1
+}
+
+// columnOffset returns the byte offset of a one based line and column, which
+// counts the characters of a line, as reported by the errors of a query.
+func (d *document) columnOffset(line, column int) int {
+	if line < 1 {
+		return 0
+	}
+	if line > len(d.lines) {
+		return len(d.text)
+	}
+	offset := d.lines[line-1]
+	for i := 1; i < column && offset < len(d.text); i++ {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		if r == '\n' {
+			break
+		}
+		offset += size
+	}
+	return offset
+}
@@ -0,0 +1,463 @@
+// Package lsp implements a Language Server Protocol server for path queries,
+// offering diagnostics, formatting, hover and completion.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+	"github.com/spoke-d/path/complete"
+	"github.com/spoke-d/path/schema"
+)
+
+// The error codes of JSON-RPC and the Language Server Protocol.
+const (
+	codeParseError       = -32700
+	codeInvalidRequest   = -32600
+	codeMethodNotFound   = -32601
+	codeInvalidParams    = -32602
+	codeServerNotStarted = -32002
+)
+
+// Option configures a server.
+type Option func(*Server)
+
+// WithSchema checks every document against the schema, reporting any problems
+// as diagnostics, and uses the schema to complete identifiers.
+func WithSchema(s *schema.Schema) Option {
+	return func(server *Server) {
+		server.schema = s
+	}
+}
+
+// Server is a Language Server Protocol server for path queries. A server
+// handles a single client.
+type Server struct {
+	schema    *schema.Schema
+	documents map[string]*document
+
+	mutex       sync.Mutex
+	writer      io.Writer
+	initialized bool
+	shutdown    bool
+}
+
+// NewServer creates a new server.
+func NewServer(options ...Option) *Server {
+	s := &Server{
+		documents: make(map[string]*document),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+type request struct {
+	ID     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// Serve reads messages from the reader and writes the replies to the writer,
+// until the client sends the exit notification or the reader is closed.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.writer = w
+	reader := bufio.NewReader(r)
+	for {
+		body, err := readMessage(reader)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.WithStack(err)
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.reply(nil, nil, &rpcError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return errors.WithStack(err)
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return errors.Errorf("exit without shutdown")
+			}
+			return nil
+		}
+
+		result, err := s.handle(req)
+		if req.ID == nil {
+			// Notifications don't have a reply.
+			continue
+		}
+		if err := s.reply(req.ID, result, err); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+}
+
+func (s *Server) handle(req request) (interface{}, error) {
+	if !s.initialized && req.Method != "initialize" {
+		return nil, &rpcError{Code: codeServerNotStarted, Message: "server not initialized"}
+	}
+
+	switch req.Method {
+	case "initialize":
+		s.initialized = true
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync: TextDocumentSyncFull,
+				HoverProvider:    true,
+				CompletionProvider: &CompletionOptions{
+					TriggerCharacters: []string{".", "["},
+				},
+				DocumentFormattingProvider: true,
+			},
+			ServerInfo: ServerInfo{
+				Name: "path-lsp",
+			},
+		}, nil
+
+	case "initialized":
+		return nil, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.update(params.TextDocument.URI, params.TextDocument.Text)
+
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		if num := len(params.ContentChanges); num > 0 {
+			return nil, s.update(params.TextDocument.URI, params.ContentChanges[num-1].Text)
+		}
+		return nil, nil
+
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		return nil, s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+
+	case "textDocument/formatting":
+		var params DocumentFormattingParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.format(doc), nil
+
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.hover(doc, doc.offset(params.Position)), nil
+
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.complete(doc, doc.offset(params.Position)), nil
+	}
+
+	if strings.HasPrefix(req.Method, "$/") {
+		// Optional notifications and requests can be ignored.
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+}
+
+func (s *Server) document(uri string) (*document, error) {
+	doc, ok := s.documents[uri]
+	if !ok {
+		return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("document %q not found", uri)}
+	}
+	return doc, nil
+}
+
+// update replaces the text of a document and publishes the diagnostics of
+// the new text.
+func (s *Server) update(uri, text string) error {
+	doc := newDocument(text)
+	s.documents[uri] = doc
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: s.diagnostics(doc),
+	})
+}
+
+func (s *Server) diagnostics(doc *document) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0)
+
+	query, err := path.Parse(doc.text)
+	if err != nil {
+		parseErrs, ok := errors.Cause(err).(path.ParseErrors)
+		if !ok {
+			return append(diagnostics, Diagnostic{
+				Range:    doc.span(0, nextRune(doc.text, 0)),
+				Severity: SeverityError,
+				Source:   "path",
+				Message:  err.Error(),
+			})
+		}
+		for _, parseErr := range parseErrs {
+			offset := doc.columnOffset(parseErr.Pos.Line, parseErr.Pos.Column)
+			diagnostics = append(diagnostics, Diagnostic{
+				Range:    doc.span(offset, nextRune(doc.text, offset)),
+				Severity: SeverityError,
+				Source:   "path",
+				Message:  parseErr.Error(),
+			})
+		}
+		return diagnostics
+	}
+
+	if s.schema != nil {
+		for _, diagnostic := range schema.Check(query, s.schema) {
+			offset := diagnostic.Pos.Offset
+			end := offset
+			if nodes := path.NodesAt(query.AST(), offset); len(nodes) > 0 {
+				end = nodes[len(nodes)-1].End().Offset
+			}
+			if end <= offset {
+				end = nextRune(doc.text, offset)
+			}
+			diagnostics = append(diagnostics, Diagnostic{
+				Range:    doc.span(offset, end),
+				Severity: SeverityWarning,
+				Source:   "path",
+				Message:  diagnostic.Error(),
+			})
+		}
+	}
+	return diagnostics
+}
+
+func (s *Server) format(doc *document) []TextEdit {
+	query, err := path.Parse(doc.text)
+	if err != nil {
+		// Documents with errors are left alone.
+		return []TextEdit{}
+	}
+	text := query.Format() + "\n"
+	if text == doc.text {
+		return []TextEdit{}
+	}
+	return []TextEdit{{
+		Range:   doc.span(0, len(doc.text)),
+		NewText: text,
+	}}
+}
+
+func (s *Server) hover(doc *document, offset int) *Hover {
+	query, err := path.Parse(doc.text)
+	if err != nil {
+		return nil
+	}
+	nodes := path.NodesAt(query.AST(), offset)
+	if len(nodes) == 0 {
+		return nil
+	}
+	node := nodes[len(nodes)-1]
+
+	value := fmt.Sprintf("**%s**\n\n```\n%s\n```", nodeType(node), path.Format(node))
+	if s.schema != nil {
+		if t := hoverType(nodes, s.schema); t != nil {
+			value += fmt.Sprintf("\n\nType: %s", t.Type)
+		}
+	}
+	span := doc.span(nodeStart(node), node.End().Offset)
+	return &Hover{
+		Contents: MarkupContent{
+			Kind:  "markdown",
+			Value: value,
+		},
+		Range: &span,
+	}
+}
+
+func (s *Server) complete(doc *document, offset int) CompletionList {
+	res := complete.Complete(doc.text, offset, complete.FromSchema(s.schema))
+
+	span := doc.span(res.Start, res.End)
+	items := make([]CompletionItem, 0, len(res.Candidates))
+	for _, candidate := range res.Candidates {
+		kind := CompletionItemKindField
+		if candidate.Kind == complete.KindOperator {
+			kind = CompletionItemKindOperator
+		}
+		items = append(items, CompletionItem{
+			Label:  candidate.Label,
+			Kind:   kind,
+			Detail: candidate.Detail,
+			TextEdit: &TextEdit{
+				Range:   span,
+				NewText: candidate.Insert,
+			},
+		})
+	}
+	return CompletionList{
+		Items: items,
+	}
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, err error) error {
+	msg := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+	}
+	if err != nil {
+		rpcErr, ok := err.(*rpcError)
+		if !ok {
+			rpcErr = &rpcError{Code: codeInvalidRequest, Message: err.Error()}
+		}
+		msg["error"] = rpcErr
+	} else {
+		msg["result"] = result
+	}
+	return s.write(msg)
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return s.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	})
+}
+
+func (s *Server) write(msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := fmt.Fprintf(s.writer, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = s.writer.Write(body)
+	return errors.WithStack(err)
+}
+
+// readMessage reads the body of a message, which is preceded by headers
+// holding the length of the body.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	headers, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, errors.WithStack(err)
+	}
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil {
+		return nil, errors.Errorf("invalid Content-Length %q", headers.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return body, nil
+}
+
+func unmarshalParams(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func nodeType(e path.Expression) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", e), "*path.")
+}
+
+// nodeStart returns the offset of the first token of the expression.
+func nodeStart(e path.Expression) int {
+	offset := e.Pos().Offset
+	path.Inspect(e, func(e path.Expression) bool {
+		if e != nil && e.Pos().Offset < offset {
+			offset = e.Pos().Offset
+		}
+		return true
+	})
+	return offset
+}
+
+// hoverType returns the schema of the innermost expression, by following the
+// scope each expression is run against from the root.
+func hoverType(nodes []path.Expression, s *schema.Schema) *schema.Schema {
+	scope := s
+	for i := 0; i+1 < len(nodes); i++ {
+		switch parent := nodes[i].(type) {
+		case *path.AccessorExpression:
+			if nodes[i+1] == parent.Right {
+				scope = schema.TypeOf(parent.Left, scope)
+			}
+		case *path.IndexExpression:
+			if nodes[i+1] == parent.Index {
+				scope = schema.TypeOf(parent.Left, scope)
+			}
+		}
+		if scope == nil {
+			return nil
+		}
+	}
+	return schema.TypeOf(nodes[len(nodes)-1], scope)
+}
+
+func nextRune(text string, offset int) int {
+	if offset >= len(text) {
+		return offset
+	}
+	_, size := utf8.DecodeRuneInString(text[offset:])
+	return offset + size
+}
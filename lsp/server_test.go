@@ -0,0 +1,285 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spoke-d/path/schema"
+)
+
+type message struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type client struct {
+	t      *testing.T
+	writer io.WriteCloser
+	reader *bufio.Reader
+	done   chan error
+	id     int
+}
+
+func newClient(t *testing.T, options ...Option) *client {
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		err := NewServer(options...).Serve(serverReader, serverWriter)
+		serverWriter.Close()
+		done <- err
+	}()
+
+	c := &client{
+		t:      t,
+		writer: clientWriter,
+		reader: bufio.NewReader(clientReader),
+		done:   done,
+	}
+	c.call("initialize", map[string]interface{}{}, nil)
+	c.notify("initialized", map[string]interface{}{})
+	return c
+}
+
+func (c *client) send(msg map[string]interface{}) {
+	msg["jsonrpc"] = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := fmt.Fprintf(c.writer, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *client) read() message {
+	body, err := readMessage(c.reader)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		c.t.Fatal(err)
+	}
+	return msg
+}
+
+// call sends a request and waits for the reply, returning the error of the
+// reply.
+func (c *client) call(method string, params, result interface{}) *rpcError {
+	c.id++
+	c.send(map[string]interface{}{"id": c.id, "method": method, "params": params})
+	for {
+		msg := c.read()
+		if msg.ID == nil || *msg.ID != c.id {
+			continue
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return nil
+	}
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.send(map[string]interface{}{"method": method, "params": params})
+}
+
+func (c *client) diagnostics() PublishDiagnosticsParams {
+	msg := c.read()
+	if msg.Method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("expected diagnostics, got %q", msg.Method)
+	}
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		c.t.Fatal(err)
+	}
+	return params
+}
+
+func (c *client) open(uri, text string) PublishDiagnosticsParams {
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "path", Text: text},
+	})
+	return c.diagnostics()
+}
+
+func (c *client) close() {
+	if err := c.call("shutdown", nil, nil); err != nil {
+		c.t.Fatal(err)
+	}
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func testSchema() *schema.Schema {
+	return schema.Object(map[string]*schema.Field{
+		"person": schema.Required(schema.Object(map[string]*schema.Field{
+			"name": schema.Required(schema.String()),
+			"age":  schema.Optional(schema.Number()),
+		})),
+	})
+}
+
+func TestServerDiagnostics(t *testing.T) {
+	c := newClient(t, WithSchema(testSchema()))
+	defer c.close()
+
+	params := c.open("file:///a.path", "person.name")
+	if len(params.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %v", params.Diagnostics)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{URI: "file:///a.path"},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "person\n.nickname"}},
+	})
+	params = c.diagnostics()
+	if len(params.Diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", params.Diagnostics)
+	}
+	diagnostic := params.Diagnostics[0]
+	if expected := (Range{Start: Position{Line: 1, Character: 1}, End: Position{Line: 1, Character: 9}}); diagnostic.Range != expected {
+		t.Errorf("expected range %v, got %v", expected, diagnostic.Range)
+	}
+	if diagnostic.Severity != SeverityWarning || !strings.Contains(diagnostic.Message, `unknown field "nickname"`) {
+		t.Errorf("unexpected diagnostic %v", diagnostic)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{URI: "file:///a.path"},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "person.name ==\n  ] "}},
+	})
+	params = c.diagnostics()
+	if len(params.Diagnostics) == 0 {
+		t.Fatalf("expected diagnostics")
+	}
+	diagnostic = params.Diagnostics[0]
+	if expected := (Range{Start: Position{Line: 1, Character: 2}, End: Position{Line: 1, Character: 3}}); diagnostic.Range != expected {
+		t.Errorf("expected range %v, got %v", expected, diagnostic.Range)
+	}
+	if diagnostic.Severity != SeverityError || !strings.HasPrefix(diagnostic.Message, "Syntax Error:") {
+		t.Errorf("unexpected diagnostic %v", diagnostic)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{URI: "file:///a.path"},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "a == ]\nb == ]"}},
+	})
+	params = c.diagnostics()
+	if len(params.Diagnostics) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %v", params.Diagnostics)
+	}
+	for i, diagnostic := range params.Diagnostics {
+		if expected := (Range{Start: Position{Line: i, Character: 5}, End: Position{Line: i, Character: 6}}); diagnostic.Range != expected {
+			t.Errorf("expected range %v, got %v", expected, diagnostic.Range)
+		}
+	}
+
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: "file:///a.path"},
+	})
+	params = c.diagnostics()
+	if params.Diagnostics == nil || len(params.Diagnostics) != 0 {
+		t.Errorf("expected diagnostics to be cleared, got %v", params.Diagnostics)
+	}
+}
+
+func TestServerFormatting(t *testing.T) {
+	c := newClient(t)
+	defer c.close()
+
+	c.open("file:///a.path", "person .(name==\"fred\")")
+
+	var edits []TextEdit
+	if err := c.call("textDocument/formatting", DocumentFormattingParams{
+		TextDocument: TextDocumentIdentifier{URI: "file:///a.path"},
+	}, &edits); err != nil {
+		t.Fatal(err)
+	}
+	expected := []TextEdit{{
+		Range:   Range{End: Position{Character: 22}},
+		NewText: "person.(name == \"fred\")\n",
+	}}
+	if !reflect.DeepEqual(edits, expected) {
+		t.Errorf("expected %v, got %v", expected, edits)
+	}
+}
+
+func TestServerHover(t *testing.T) {
+	c := newClient(t, WithSchema(testSchema()))
+	defer c.close()
+
+	c.open("file:///a.path", "person.name")
+
+	var hover Hover
+	if err := c.call("textDocument/hover", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: "file:///a.path"},
+		Position:     Position{Character: 8},
+	}, &hover); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(hover.Contents.Value, "Identifier") || !strings.Contains(hover.Contents.Value, "Type: string") {
+		t.Errorf("unexpected hover %q", hover.Contents.Value)
+	}
+	if expected := (Range{Start: Position{Character: 7}, End: Position{Character: 11}}); hover.Range == nil || *hover.Range != expected {
+		t.Errorf("expected range %v, got %v", expected, hover.Range)
+	}
+}
+
+func TestServerCompletion(t *testing.T) {
+	c := newClient(t, WithSchema(testSchema()))
+	defer c.close()
+
+	c.open("file:///a.path", "person.n")
+
+	var list CompletionList
+	if err := c.call("textDocument/completion", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: "file:///a.path"},
+		Position:     Position{Character: 8},
+	}, &list); err != nil {
+		t.Fatal(err)
+	}
+	expected := []CompletionItem{{
+		Label:  "name",
+		Kind:   CompletionItemKindField,
+		Detail: "string",
+		TextEdit: &TextEdit{
+			Range:   Range{Start: Position{Character: 7}, End: Position{Character: 8}},
+			NewText: "name",
+		},
+	}}
+	if !reflect.DeepEqual(list.Items, expected) {
+		t.Errorf("expected %v, got %v", expected, list.Items)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	c := newClient(t)
+	defer c.close()
+
+	if err := c.call("textDocument/hover", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: "file:///missing.path"},
+	}, nil); err == nil || err.Code != codeInvalidParams {
+		t.Errorf("expected invalid params, got %v", err)
+	}
+	if err := c.call("unknown", nil, nil); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("expected method not found, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package path
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Location is the normalized location of a value, as the list of identifiers
+// that have to be accessed from the root scope to reach the value.
+type Location []string
+
+// String returns the location in the normalized form, ["users"]["alice"]
+func (l Location) String() string {
+	var buf strings.Builder
+	for _, ident := range l {
+		buf.WriteString("[")
+		buf.WriteString(strconv.Quote(ident))
+		buf.WriteString("]")
+	}
+	return buf.String()
+}
+
+// Path returns a path that accesses the location, which can be run against the
+// root scope to get the value again.
+func (l Location) Path() (Path, error) {
+	if len(l) == 0 {
+		return Path{}, errors.Errorf("empty location")
+	}
+	b := Key(l[0])
+	for _, ident := range l[1:] {
+		b = b.Key(ident)
+	}
+	return Build(b)
+}
+
+// Match is a value matched by a query, along with the location of the value.
+type Match struct {
+	// Value is the scope that was matched.
+	Value Scope
+	// Location is the location of the value from the root scope. Values that
+	// are not found in the root scope, such as string literals, have no
+	// location.
+	Location Location
+}
+
+// RunPaths runs the query over a given scope, returning every value that
+// matches along with its location. The values are the same as the values
+// returned by Iter, apart from a subset of a collection, such as the result
+// of a filter, which isn't the value stored at any location. Instead, each
+// member of the subset is a match of its own, with the location of the
+// member.
+func (q Path) RunPaths(scope Scope) ([]Match, error) {
+	var matches []Match
+	iter := q.Iter(locatedScope{scope: scope})
+	for {
+		value, ok := iter.Next()
+		if !ok {
+			break
+		}
+		matches = append(matches, unlocate(value)...)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return matches, nil
+}
+
+// locatedScope wraps a scope, keeping track of the location of the scope as
+// identifiers are accessed.
+type locatedScope struct {
+	scope    Scope
+	location Location
+	// subset is true if the scope is a subset of a collection, in which case
+	// it has no location and its members hold their own locations.
+	subset  bool
+	members []locatedScope
+}
+
+// GetAllIdents returns all the identifiers for a given scope.
+func (s locatedScope) GetAllIdents() []string {
+	return s.scope.GetAllIdents()
+}
+
+// GetIdentValue returns the value of the identifier in a given scope.
+func (s locatedScope) GetIdentValue(v string) (Scope, error) {
+	value, err := s.scope.GetIdentValue(v)
+	if err != nil {
+		return nil, err
+	}
+	// The identifiers of a subset, such as the indexes of a filtered list,
+	// don't always match those of the collection, so its values have no
+	// location.
+	if s.subset {
+		return value, nil
+	}
+	// A string scope returns itself for every identifier.
+	if _, ok := s.scope.(StringScope); ok {
+		return s.with(value, s.location), nil
+	}
+	location := make(Location, len(s.location), len(s.location)+1)
+	copy(location, s.location)
+	return s.with(value, append(location, v)), nil
+}
+
+// RunOperation attempts to run an operation on a given scope. Comparing a
+// value returns the value itself, so it keeps the same location, whereas
+// running an operation on a collection returns the subset of the members
+// that match, each with the location of the member.
+func (s locatedScope) RunOperation(op Operation, scope Scope) (Scope, error) {
+	value, err := s.scope.RunOperation(op, scope)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	members, ok := s.candidates()
+	if !ok {
+		return s.with(value, s.location), nil
+	}
+	// The members that match are found in the same way as a collection
+	// finds them, by running the operation with each member.
+	res := locatedScope{
+		scope:  value,
+		subset: true,
+	}
+	for _, member := range members {
+		if _, err := scope.RunOperation(op, member.scope); err == nil {
+			res.members = append(res.members, member)
+		}
+	}
+	return res, nil
+}
+
+// candidates returns the located members of a collection, or false if the
+// scope isn't a collection.
+func (s locatedScope) candidates() ([]locatedScope, bool) {
+	if s.subset {
+		return s.members, true
+	}
+	idents := s.scope.GetAllIdents()
+	if len(idents) == 0 {
+		return nil, false
+	}
+	members := make([]locatedScope, 0, len(idents))
+	for _, ident := range idents {
+		value, err := s.scope.GetIdentValue(ident)
+		if err != nil {
+			continue
+		}
+		location := make(Location, len(s.location), len(s.location)+1)
+		copy(location, s.location)
+		members = append(members, locatedScope{
+			scope:    value,
+			location: append(location, ident),
+		})
+	}
+	return members, true
+}
+
+func (s locatedScope) with(value Scope, location Location) Scope {
+	if value == nil {
+		return nil
+	}
+	return locatedScope{
+		scope:    value,
+		location: location,
+	}
+}
+
+// unlocated returns the scope without its location, so that it can be the
+// right of an operation on any scope, such as a string literal.
+func unlocated(scope Scope) Scope {
+	if s, ok := scope.(locatedScope); ok {
+		return s.scope
+	}
+	return scope
+}
+
+// unlocate returns the matches for a value returned from running a query over
+// a located scope. A subset returns a match for each of its members.
+func unlocate(value Scope) []Match {
+	s, ok := value.(locatedScope)
+	if !ok {
+		return []Match{{Value: value}}
+	}
+	if !s.subset {
+		return []Match{{Value: s.scope, Location: s.location}}
+	}
+	matches := make([]Match, len(s.members))
+	for i, member := range s.members {
+		matches[i] = Match{
+			Value:    member.scope,
+			Location: member.location,
+		}
+	}
+	return matches
+}
@@ -0,0 +1,137 @@
+package path_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/spoke-d/path"
+	"github.com/spoke-d/path/set"
+)
+
+func matchScope() path.Scope {
+	return set.MakeSet(map[string]interface{}{
+		"users": map[string]interface{}{
+			"alice": map[string]interface{}{
+				"name": "alice",
+				"role": "admin",
+			},
+			"bob": map[string]interface{}{
+				"name": "bob",
+				"role": "user",
+			},
+		},
+	})
+}
+
+func TestRunPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "identifier", query: `users.alice.name`, expected: []string{`["users"]["alice"]["name"]`}},
+		{name: "index", query: `users["bob"]`, expected: []string{`["users"]["bob"]`}},
+		{name: "descent", query: `users..`, expected: []string{`["users"]["alice"]`, `["users"]["bob"]`}},
+		{name: "filter", query: `users.bob.(role == "user")`, expected: []string{`["users"]["bob"]["role"]`}},
+		{name: "statements", query: `users.alice.role; users.bob.role`, expected: []string{`["users"]["alice"]["role"]`, `["users"]["bob"]["role"]`}},
+		{name: "conditional or", query: `users.bob.role || users.alice.role`, expected: []string{`["users"]["bob"]["role"]`}},
+		{name: "literal", query: `"literal"`, expected: []string{``}},
+		{name: "literal on the left", query: `users.alice.("alice" == name)`, expected: []string{``}},
+		{name: "filtering group", query: `users.(alice == "admin")`, expected: []string{`["users"]["alice"]["role"]`}},
+		{name: "filtering group of a filter", query: `users.((alice != "user") == "admin")`, expected: []string{`["users"]["alice"]["role"]`}},
+		{name: "filtering group no members", query: `users.(alice == "nobody")`},
+		{name: "value of a filtering group", query: `users.(alice == "admin").role`, expected: []string{``}},
+		{name: "literal on the right", query: `users.alice.(name == "alice")`, expected: []string{`["users"]["alice"]["name"]`}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, err := path.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			matches, err := query.RunPaths(matchScope())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var locations []string
+			for _, match := range matches {
+				locations = append(locations, match.Location.String())
+			}
+			sort.Strings(locations)
+			if !reflect.DeepEqual(locations, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, locations)
+			}
+		})
+	}
+}
+
+func TestRunPathsValues(t *testing.T) {
+	query, err := path.Parse(`users.alice.name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := query.RunPaths(matchScope())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+	if expected := path.MakeStringScope("alice"); matches[0].Value != expected {
+		t.Errorf("expected %v, got %v", expected, matches[0].Value)
+	}
+}
+
+func TestRunPathsFilteringGroup(t *testing.T) {
+	query, err := path.Parse(`(env == "prod")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := query.RunPaths(set.MakeSet(map[string]interface{}{
+		"env": map[string]interface{}{
+			"a": "prod",
+			"b": "dev",
+		},
+		"keep": "x",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+	if expected, got := `["env"]["a"]`, matches[0].Location.String(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	if expected := path.MakeStringScope("prod"); matches[0].Value != expected {
+		t.Errorf("expected %v, got %v", expected, matches[0].Value)
+	}
+}
+
+func TestLocationPath(t *testing.T) {
+	location := path.Location{"users", "bob", "role"}
+
+	query, err := location.Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, got := `["users"]["bob"]["role"]`, query.Format(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	value, err := query.First(matchScope())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := path.MakeStringScope("user"); value != expected {
+		t.Errorf("expected %v, got %v", expected, value)
+	}
+
+	if _, err := path.Location(nil).Path(); err == nil {
+		t.Error("expected error for empty location")
+	}
+}
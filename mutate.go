@@ -0,0 +1,134 @@
+package path
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Set sets every value matched by the query to the given value. The parent
+// of every match has to be a MutableScope.
+// Returns the number of values that were set.
+func (q Path) Set(scope Scope, value Scope) (int, error) {
+	return q.Update(scope, func(Scope) Scope {
+		return value
+	})
+}
+
+// Update replaces every value matched by the query with the result of the
+// function, which is called with the current value. The parent of every match
+// has to be a MutableScope.
+// The parent of every match is found before any value is replaced, so that
+// an error leaves the scope untouched.
+// Returns the number of values that were updated.
+func (q Path) Update(scope Scope, fn func(Scope) Scope) (int, error) {
+	targets, err := q.mutableTargets(scope)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	values := make([]Scope, len(targets))
+	for i, target := range targets {
+		values[i] = fn(target.match.Value)
+	}
+	for i, target := range targets {
+		if err := target.parent.SetIdentValue(target.ident, values[i]); err != nil {
+			return i, errors.WithStack(err)
+		}
+	}
+	return len(targets), nil
+}
+
+// Delete removes every value matched by the query. The parent of every match
+// has to be a MutableScope.
+// The parent of every match is found before any value is removed, so that
+// an error leaves the scope untouched.
+// Returns the number of values that were deleted.
+func (q Path) Delete(scope Scope) (int, error) {
+	targets, err := q.mutableTargets(scope)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	// Delete the deepest values first, so that deleting a value doesn't
+	// remove the parent of another match.
+	sort.SliceStable(targets, func(i, j int) bool {
+		return len(targets[i].match.Location) > len(targets[j].match.Location)
+	})
+	for i, target := range targets {
+		if err := target.parent.DeleteIdent(target.ident); err != nil {
+			return i, errors.WithStack(err)
+		}
+	}
+	return len(targets), nil
+}
+
+// target is a match along with the mutable parent that holds it.
+type target struct {
+	match  Match
+	parent MutableScope
+	ident  string
+}
+
+// mutableTargets returns the matches of the query along with their parents.
+func (q Path) mutableTargets(scope Scope) ([]target, error) {
+	matches, err := q.mutableMatches(scope)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	targets := make([]target, len(matches))
+	for i, match := range matches {
+		parent, ident, err := resolveParent(scope, match.Location)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		targets[i] = target{
+			match:  match,
+			parent: parent,
+			ident:  ident,
+		}
+	}
+	return targets, nil
+}
+
+// mutableMatches returns the matches of the query that can be modified, with
+// any duplicate locations removed. Values without a location, such as values
+// accessed within the subset of a filter, can't be modified.
+func (q Path) mutableMatches(scope Scope) ([]Match, error) {
+	matches, err := q.RunPaths(scope)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var (
+		res  []Match
+		seen = make(map[string]bool)
+	)
+	for _, match := range matches {
+		if len(match.Location) == 0 {
+			return nil, errors.Errorf("unable to modify %v, as it has no location in the scope", match.Value)
+		}
+		location := match.Location.String()
+		if seen[location] {
+			continue
+		}
+		seen[location] = true
+		res = append(res, match)
+	}
+	return res, nil
+}
+
+// resolveParent returns the mutable parent of the location, along with the
+// identifier of the location in the parent.
+func resolveParent(scope Scope, location Location) (MutableScope, string, error) {
+	last := len(location) - 1
+	for _, ident := range location[:last] {
+		var err error
+		if scope, err = scope.GetIdentValue(ident); err != nil {
+			return nil, "", errors.WithStack(err)
+		}
+	}
+	parent, ok := scope.(MutableScope)
+	if !ok {
+		return nil, "", errors.Errorf("unable to modify %s, as %T is not mutable", location, scope)
+	}
+	return parent, location[last], nil
+}
@@ -0,0 +1,258 @@
+package path_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spoke-d/path"
+	"github.com/spoke-d/path/set"
+)
+
+func mutateDocument() map[string]interface{} {
+	return map[string]interface{}{
+		"users": map[string]interface{}{
+			"alice": map[string]interface{}{
+				"role": "admin",
+			},
+			"bob": map[string]interface{}{
+				"role": "user",
+			},
+		},
+	}
+}
+
+func TestSet(t *testing.T) {
+	doc := mutateDocument()
+	query, err := path.Parse(`users.alice.role; users.bob.role`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := query.Set(set.MakeSet(doc), path.MakeStringScope("guest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 values set, got %d", n)
+	}
+	expected := map[string]interface{}{
+		"users": map[string]interface{}{
+			"alice": map[string]interface{}{
+				"role": "guest",
+			},
+			"bob": map[string]interface{}{
+				"role": "guest",
+			},
+		},
+	}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("expected %v, got %v", expected, doc)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	doc := mutateDocument()
+	query, err := path.Parse(`users..`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := query.Update(set.MakeSet(doc), func(value path.Scope) path.Scope {
+		role, err := value.GetIdentValue("role")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return set.MakeSet(map[string]interface{}{
+			"role": role.(path.StringScope).Value() + "!",
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 values updated, got %d", n)
+	}
+	expected := map[string]interface{}{
+		"users": map[string]interface{}{
+			"alice": map[string]interface{}{
+				"role": "admin!",
+			},
+			"bob": map[string]interface{}{
+				"role": "user!",
+			},
+		},
+	}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("expected %v, got %v", expected, doc)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	doc := mutateDocument()
+	query, err := path.Parse(`users.bob; users.bob.role; users.alice.(role == "admin")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := query.Delete(set.MakeSet(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 values deleted, got %d", n)
+	}
+	expected := map[string]interface{}{
+		"users": map[string]interface{}{
+			"alice": map[string]interface{}{},
+		},
+	}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("expected %v, got %v", expected, doc)
+	}
+}
+
+func filterDocument() map[string]interface{} {
+	return map[string]interface{}{
+		"env": map[string]interface{}{
+			"a": "prod",
+			"b": "dev",
+		},
+		"keep": "x",
+	}
+}
+
+func TestDeleteFiltered(t *testing.T) {
+	doc := filterDocument()
+	query, err := path.Parse(`(env == "prod")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := query.Delete(set.MakeSet(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 value deleted, got %d", n)
+	}
+	expected := map[string]interface{}{
+		"env": map[string]interface{}{
+			"b": "dev",
+		},
+		"keep": "x",
+	}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("expected %v, got %v", expected, doc)
+	}
+}
+
+func TestUpdateFiltered(t *testing.T) {
+	doc := filterDocument()
+	query, err := path.Parse(`(env != "prod")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := query.Update(set.MakeSet(doc), func(value path.Scope) path.Scope {
+		return path.MakeStringScope(value.(path.StringScope).Value() + "!")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 value updated, got %d", n)
+	}
+	expected := map[string]interface{}{
+		"env": map[string]interface{}{
+			"a": "prod",
+			"b": "dev!",
+		},
+		"keep": "x",
+	}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("expected %v, got %v", expected, doc)
+	}
+}
+
+func TestMutateLeavesScopeOnError(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(path.Path, path.Scope) (int, error)
+	}{
+		{name: "set", mutate: func(q path.Path, scope path.Scope) (int, error) {
+			return q.Set(scope, path.MakeStringScope("value"))
+		}},
+		{name: "delete", mutate: func(q path.Path, scope path.Scope) (int, error) {
+			return q.Delete(scope)
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc := mutateDocument()
+			doc["tags"] = []interface{}{"a"}
+			// The second statement can't be modified, as lists aren't
+			// mutable.
+			query, err := path.Parse(`users.alice.role; tags["0"]`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n, err := test.mutate(query, set.MakeSet(doc))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if n != 0 {
+				t.Errorf("expected no values changed, got %d", n)
+			}
+			expected := mutateDocument()
+			expected["tags"] = []interface{}{"a"}
+			if !reflect.DeepEqual(doc, expected) {
+				t.Errorf("expected %v, got %v", expected, doc)
+			}
+		})
+	}
+}
+
+func TestMutateErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		scope path.Scope
+	}{
+		{name: "literal", query: `"literal"`, scope: set.MakeSet(mutateDocument())},
+		{name: "missing", query: `users.carol`, scope: set.MakeSet(mutateDocument())},
+		{name: "immutable", query: `users.alice.role`, scope: immutableScope{set.MakeSet(mutateDocument())}},
+		{name: "value of a filter", query: `(env == "prod").a`, scope: set.MakeSet(filterDocument())},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, err := path.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := query.Set(test.scope, path.MakeStringScope("value")); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// immutableScope hides the mutable methods of a scope.
+type immutableScope struct {
+	scope path.Scope
+}
+
+func (s immutableScope) GetAllIdents() []string {
+	return s.scope.GetAllIdents()
+}
+
+func (s immutableScope) GetIdentValue(v string) (path.Scope, error) {
+	value, err := s.scope.GetIdentValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return immutableScope{value}, nil
+}
+
+func (s immutableScope) RunOperation(op path.Operation, scope path.Scope) (path.Scope, error) {
+	return s.scope.RunOperation(op, scope)
+}
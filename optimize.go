@@ -0,0 +1,320 @@
+package path
+
+import (
+	"sort"
+)
+
+// OptimizeOption configures how a query is optimized.
+type OptimizeOption func(*optimizer)
+
+// WithReordering allows the operands of a chain of conditional ands (&&) to
+// be reordered, so that the cheapest operands are run first.
+// This changes the order of the combined results, which is why it's not
+// enabled by default.
+func WithReordering() OptimizeOption {
+	return func(o *optimizer) {
+		o.reorder = true
+	}
+}
+
+// WithOptimizeTrace calls the trace function for every pass that changes the
+// expression, with the expression before and after the pass.
+func WithOptimizeTrace(fn func(pass string, before, after Expression)) OptimizeOption {
+	return func(o *optimizer) {
+		o.trace = fn
+	}
+}
+
+type optimizer struct {
+	reorder bool
+	trace   func(string, Expression, Expression)
+}
+
+// maxOptimizePasses limits the number of times all the passes are run, when
+// trying to find a stable expression.
+const maxOptimizePasses = 8
+
+// Optimize returns a simplified version of the expression, which gives the
+// same results when run. The expression given is not modified.
+//
+// The following passes are run until the expression no longer changes:
+//
+//   - flatten: nested accessors, such as a.(b.c), are flattened to a.b.c and
+//     redundant index expressions are removed.
+//   - fold: comparisons of a string literal with itself using ==, <= or >=
+//     are replaced by the string, as comparing a value to itself always
+//     matches.
+//   - dedupe: duplicate operands of || chains are removed, as only one of
+//     them is ever returned. Duplicate statements are also removed, along
+//     with their comments, which drops their repeated results. The operands
+//     of && chains are kept, as each operand is a result of its own.
+//   - reorder: the operands of && chains are sorted by their estimated cost,
+//     if WithReordering is used.
+func Optimize(e Expression, options ...OptimizeOption) Expression {
+	o := &optimizer{}
+	for _, option := range options {
+		option(o)
+	}
+
+	passes := []struct {
+		name string
+		fn   func(Expression) Expression
+	}{
+		{name: "flatten", fn: flatten},
+		{name: "fold", fn: fold},
+		{name: "dedupe", fn: dedupe},
+	}
+	if o.reorder {
+		passes = append(passes, struct {
+			name string
+			fn   func(Expression) Expression
+		}{name: "reorder", fn: reorder})
+	}
+
+	e = Clone(e)
+	for i := 0; i < maxOptimizePasses; i++ {
+		var changed bool
+		for _, pass := range passes {
+			var before Expression
+			if o.trace != nil {
+				before = Clone(e)
+			}
+			source := Format(e)
+			e = pass.fn(e)
+			if Format(e) == source {
+				continue
+			}
+			changed = true
+			if o.trace != nil {
+				o.trace(pass.name, before, Clone(e))
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return e
+}
+
+// Optimize returns a simplified version of the path, see Optimize for the
+// details.
+func (q Path) Optimize(options ...OptimizeOption) Path {
+	if q.ast == nil {
+		return q
+	}
+	ast, ok := Optimize(q.ast, options...).(*QueryExpression)
+	if !ok {
+		return q
+	}
+	return Path{
+		ast: ast,
+	}
+}
+
+// flatten removes unnecessary nesting of expressions.
+func flatten(e Expression) Expression {
+	return Apply(e, nil, func(c *Cursor) bool {
+		switch node := c.Node().(type) {
+		case *AccessorExpression:
+			switch right := node.Right.(type) {
+			case *AccessorExpression:
+				// a.(b.c) becomes a.b.c
+				c.Replace(&AccessorExpression{
+					Token: right.Token,
+					Left: &AccessorExpression{
+						Token: node.Token,
+						Left:  node.Left,
+						Right: right.Left,
+					},
+					Right: right.Right,
+				})
+			case *IndexExpression:
+				// a.(b[c]) becomes a.b[c]
+				c.Replace(&IndexExpression{
+					Token: right.Token,
+					Left: &AccessorExpression{
+						Token: node.Token,
+						Left:  node.Left,
+						Right: right.Left,
+					},
+					Index: right.Index,
+				})
+			case *AccessExpression:
+				// a.[b] becomes a[b]
+				c.Replace(&IndexExpression{
+					Token: right.Token,
+					Left:  node.Left,
+					Index: right.Index,
+				})
+			}
+		case *IndexExpression:
+			if index, ok := node.Index.(*AccessExpression); ok {
+				// a[[b]] becomes a[b]
+				node.Index = index.Index
+			}
+		case *AccessExpression:
+			if index, ok := node.Index.(*AccessExpression); ok {
+				// [[b]] becomes [b]
+				node.Index = index.Index
+			}
+		}
+		return true
+	})
+}
+
+// fold replaces comparisons that always match.
+func fold(e Expression) Expression {
+	return Apply(e, nil, func(c *Cursor) bool {
+		node, ok := c.Node().(*InfixExpression)
+		if !ok {
+			return true
+		}
+		switch node.Token.Type {
+		case EQ, LE, GE:
+		default:
+			return true
+		}
+		left, ok := node.Left.(*String)
+		if !ok {
+			return true
+		}
+		if right, ok := node.Right.(*String); ok && left.Token.Literal == right.Token.Literal {
+			c.Replace(left)
+		}
+		return true
+	})
+}
+
+// dedupe removes duplicate operands of || chains and duplicate statements.
+func dedupe(e Expression) Expression {
+	e = Apply(e, nil, func(c *Cursor) bool {
+		node, ok := c.Node().(*InfixExpression)
+		if !ok || node.Token.Type != CONDOR {
+			return true
+		}
+		// Only rewrite the top of a chain.
+		if parent, ok := c.Parent().(*InfixExpression); ok && parent.Token.Type == node.Token.Type && c.Name() == "Left" {
+			return true
+		}
+
+		var (
+			operands []Expression
+			seen     = make(map[string]bool)
+		)
+		for _, operand := range chain(node) {
+			source := Format(operand)
+			if seen[source] {
+				continue
+			}
+			seen[source] = true
+			operands = append(operands, operand)
+		}
+		c.Replace(rebuildChain(node, operands))
+		return true
+	})
+
+	if query, ok := e.(*QueryExpression); ok {
+		var (
+			exps    []Expression
+			seen    = make(map[string]bool)
+			removed = make(map[*Comment]bool)
+		)
+		for _, exp := range query.Expressions {
+			source := Format(exp)
+			if !seen[source] {
+				seen[source] = true
+				exps = append(exps, exp)
+				continue
+			}
+			if stmt, ok := exp.(*ExpressionStatement); ok {
+				for _, comment := range stmt.Doc {
+					removed[comment] = true
+				}
+				if stmt.Comment != nil {
+					removed[stmt.Comment] = true
+				}
+			}
+		}
+		query.Expressions = exps
+
+		// The comments are shared with the original query, so the comments of
+		// the removed statements are dropped from a copy.
+		if len(removed) > 0 {
+			var comments []*Comment
+			for _, comment := range query.Comments {
+				if !removed[comment] {
+					comments = append(comments, comment)
+				}
+			}
+			query.Comments = comments
+		}
+	}
+	return e
+}
+
+// reorder sorts the operands of && chains by their estimated cost.
+func reorder(e Expression) Expression {
+	return Apply(e, nil, func(c *Cursor) bool {
+		node, ok := c.Node().(*InfixExpression)
+		if !ok || node.Token.Type != CONDAND {
+			return true
+		}
+		if parent, ok := c.Parent().(*InfixExpression); ok && parent.Token.Type == CONDAND && c.Name() == "Left" {
+			return true
+		}
+
+		operands := chain(node)
+		sort.SliceStable(operands, func(i, j int) bool {
+			return cost(operands[i]) < cost(operands[j])
+		})
+		c.Replace(rebuildChain(node, operands))
+		return true
+	})
+}
+
+// chain returns the operands of a left associative chain of the same
+// operator, a && b && c returns [a, b, c].
+func chain(node *InfixExpression) []Expression {
+	if left, ok := node.Left.(*InfixExpression); ok && left.Token.Type == node.Token.Type {
+		return append(chain(left), node.Right)
+	}
+	return []Expression{node.Left, node.Right}
+}
+
+// rebuildChain creates a left associative chain of operands, using the
+// operator of the given node.
+func rebuildChain(node *InfixExpression, operands []Expression) Expression {
+	res := operands[0]
+	for _, operand := range operands[1:] {
+		res = &InfixExpression{
+			Token:    node.Token,
+			Operator: node.Operator,
+			Left:     res,
+			Right:    operand,
+		}
+	}
+	return res
+}
+
+// cost estimates how expensive it is to run an expression, where looking up
+// an identifier has a cost of one.
+func cost(e Expression) int {
+	switch node := e.(type) {
+	case *Identifier:
+		return 1
+	case *String:
+		// A string is looked up first, before falling back to the literal.
+		return 2
+	case *DescentExpression:
+		// Descending visits every identifier of the scope.
+		return 10
+	case *InfixExpression:
+		return 1 + cost(node.Left) + cost(node.Right)
+	}
+
+	var total int
+	for _, child := range children(e) {
+		total += cost(child)
+	}
+	return total
+}
@@ -0,0 +1,112 @@
+package path
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		options  []OptimizeOption
+	}{
+		{input: `aaa.(bbb.ccc)`, expected: `aaa.bbb.ccc`},
+		{input: `aaa.(bbb.(ccc.ddd))`, expected: `aaa.bbb.ccc.ddd`},
+		{input: `aaa.(bbb["ccc"])`, expected: `aaa.bbb["ccc"]`},
+		{input: `aaa.(["bbb"])`, expected: `aaa["bbb"]`},
+		{input: `aaa.(("a" == "a") && bbb)`, expected: `aaa.("a" && bbb)`},
+		{input: `aaa.(("a" <= "a") || bbb)`, expected: `aaa.("a" || bbb)`},
+		{input: `aaa.(bbb && ("a" >= "a"))`, expected: `aaa.(bbb && "a")`},
+		{input: `aaa.("a" == "b")`, expected: `aaa.("a" == "b")`},
+		{input: `aaa.("a" != "a")`, expected: `aaa.("a" != "a")`},
+		{input: `aaa.(bbb || bbb)`, expected: `aaa.bbb`},
+		{input: `aaa.(bbb || ccc || bbb)`, expected: `aaa.(bbb || ccc)`},
+		{input: `aaa.(bbb && yyy && bbb)`, expected: `aaa.(bbb && yyy && bbb)`},
+		{input: `aaa.(bbb && bbb)`, expected: `aaa.(bbb && bbb)`},
+		{input: `aaa.bbb; aaa.bbb; aaa.(bbb)`, expected: `aaa.bbb`},
+		{input: "# first\naaa;\n# second\naaa; # trailing\nbbb", expected: "# first\naaa;\nbbb"},
+		{input: `aaa.(bbb.ccc.ddd && eee)`, expected: `aaa.(bbb.ccc.ddd && eee)`},
+		{input: `aaa.(bbb.ccc.ddd && eee)`, expected: `aaa.(eee && bbb.ccc.ddd)`, options: []OptimizeOption{WithReordering()}},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			query, err := Parse(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			source := query.Format()
+
+			optimized := query.Optimize(test.options...)
+			if got := optimized.Format(); got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+			if got := query.Format(); got != source {
+				t.Errorf("expected the original to be untouched, got %q", got)
+			}
+		})
+	}
+}
+
+func TestOptimizeResults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	root := newSuccessScope(ctrl)
+
+	tests := []string{
+		`aaa.(bbb.ccc)`,
+		`aaa.(bbb.(ddd.eee))`,
+		`aaa.(["bbb"])`,
+		`aaa.(bbb["ccc"])`,
+		`aaa.(xxx || yyy || xxx)`,
+		`aaa.(bbb && bbb)`,
+		`aaa.(("a" == "a") && bbb)`,
+		`aaa.(("a" <= "a") || bbb)`,
+		`aaa.(bbb && ("a" >= "a"))`,
+		`aaa.("a" != "a" || bbb)`,
+		`aaa.(("a" == "b") || bbb)`,
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			query, err := Parse(test)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected, err := query.Run(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := query.Optimize().Run(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(expected, got) {
+				t.Errorf("expected %v, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestOptimizeTrace(t *testing.T) {
+	query, err := Parse(`aaa.(bbb.ccc || bbb.ccc)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var passes []string
+	query.Optimize(WithOptimizeTrace(func(pass string, before, after Expression) {
+		passes = append(passes, pass+": "+Format(before)+" => "+Format(after))
+	}))
+
+	expected := []string{
+		`dedupe: aaa.(bbb.ccc || bbb.ccc) => aaa.(bbb.ccc)`,
+		`flatten: aaa.(bbb.ccc) => aaa.bbb.ccc`,
+	}
+	if !reflect.DeepEqual(passes, expected) {
+		t.Errorf("expected %q, got %q", expected, passes)
+	}
+}
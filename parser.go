@@ -2,7 +2,6 @@ package path
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -34,11 +33,16 @@ var precedence = map[TokenType]int{
 type Parser struct {
 	lex *Lexer
 
-	errors []string
+	errors ParseErrors
 
 	currentToken Token
 	peekToken    Token
 
+	// comments holds all the comments found, pending holds the comments that
+	// are yet to be attached to a statement.
+	comments []*Comment
+	pending  []*Comment
+
 	prefix map[TokenType]PrefixFunc
 	infix  map[TokenType]InfixFunc
 }
@@ -82,10 +86,11 @@ func (p *Parser) Run() (*QueryExpression, error) {
 		exp.Expressions = append(exp.Expressions, p.parseExpressionStatement())
 		p.nextToken()
 	}
-	var err error
-	if len(p.errors) > 0 {
-		err = errors.Errorf(strings.Join(p.errors, "\n"))
-		return nil, errors.WithStack(err)
+	exp.Comments = p.comments
+
+	errs := append(append(ParseErrors{}, p.lex.Errors()...), p.errors...)
+	if len(errs) > 0 {
+		return nil, errors.WithStack(errs)
 	}
 	return &exp, nil
 }
@@ -112,15 +117,44 @@ func (p *Parser) parseExpressionStatement() Expression {
 	if p.isPeekToken(SEMICOLON) {
 		p.nextToken()
 	}
+	stmt.Doc, stmt.Comment = p.attachComments(p.currentToken)
 	return stmt
 }
 
+// attachComments takes all the pending comments that are found before the end
+// of the last token, along with a trailing comment on the same line as the
+// last token.
+func (p *Parser) attachComments(last Token) ([]*Comment, *Comment) {
+	text := last.Raw
+	if text == "" {
+		text = last.Literal
+	}
+	end := endOf(last.Pos, text)
+
+	var (
+		doc      []*Comment
+		trailing *Comment
+		pending  []*Comment
+	)
+	for _, comment := range p.pending {
+		switch {
+		case comment.Pos().Offset < end.Offset:
+			doc = append(doc, comment)
+		case trailing == nil && len(pending) == 0 && comment.Pos().Line == end.Line:
+			trailing = comment
+		default:
+			pending = append(pending, comment)
+		}
+	}
+	p.pending = pending
+	return doc, trailing
+}
+
 func (p *Parser) parseExpression(precedence int) Expression {
 	prefix := p.prefix[p.currentToken.Type]
 	if prefix == nil {
 		if p.currentToken.Type != EOF {
-			msg := fmt.Sprintf("Syntax Error:%v invalid character '%s' found", p.currentToken.Pos, p.currentToken.Type)
-			p.errors = append(p.errors, msg)
+			p.errorf(p.currentToken.Pos, "invalid character '%s' found", p.currentToken.Type)
 		}
 		return nil
 	}
@@ -154,7 +188,7 @@ func (p *Parser) parseInfixExpression(left Expression) Expression {
 
 func (p *Parser) parseGroup() Expression {
 	p.nextToken()
-	if p.currentToken.Type == LPAREN && p.isCurrentToken(RPAREN) {
+	if p.isCurrentToken(RPAREN) {
 		// This is an empty group, not sure what we should do here.
 		return &Empty{
 			Token: p.currentToken,
@@ -169,31 +203,31 @@ func (p *Parser) parseGroup() Expression {
 }
 
 func (p *Parser) parseAccessor(left Expression) Expression {
+	token := p.currentToken
 	precedence := p.currentPrecedence()
 	p.nextToken()
 	right := p.parseExpression(precedence)
 
 	return &AccessorExpression{
-		Token: p.currentToken,
+		Token: token,
 		Left:  left,
 		Right: right,
 	}
 }
 
 func (p *Parser) parseAccess() Expression {
+	token := p.currentToken
 	p.nextToken()
 	index := &AccessExpression{
-		Token: p.currentToken,
+		Token: token,
 		Index: p.parseExpression(LOWEST),
 	}
 	if p.isCurrentToken(RBRACKET) {
-		msg := fmt.Sprintf("Syntax Error:%v missing index, got %s instead", p.currentToken.Pos, p.currentToken.Type)
-		p.errors = append(p.errors, msg)
+		p.errorf(p.currentToken.Pos, "missing index, got %s instead", p.currentToken.Type)
 		return nil
 	}
 	if !p.isPeekToken(RBRACKET) {
-		msg := fmt.Sprintf("Syntax Error:%v expected ']', got %s instead", p.currentToken.Pos, p.currentToken.Type)
-		p.errors = append(p.errors, msg)
+		p.errorf(p.currentToken.Pos, "expected ']', got %s instead", p.currentToken.Type)
 		return nil
 	}
 	p.nextToken()
@@ -201,20 +235,19 @@ func (p *Parser) parseAccess() Expression {
 }
 
 func (p *Parser) parseIndex(left Expression) Expression {
+	token := p.currentToken
 	p.nextToken()
 	index := &IndexExpression{
-		Token: p.currentToken,
+		Token: token,
 		Left:  left,
 		Index: p.parseExpression(LOWEST),
 	}
 	if p.isCurrentToken(RBRACKET) {
-		msg := fmt.Sprintf("Syntax Error:%v missing index, got %s instead", p.currentToken.Pos, p.currentToken.Type)
-		p.errors = append(p.errors, msg)
+		p.errorf(p.currentToken.Pos, "missing index, got %s instead", p.currentToken.Type)
 		return nil
 	}
 	if !p.isPeekToken(RBRACKET) {
-		msg := fmt.Sprintf("Syntax Error:%v expected ']', got %s instead", p.currentToken.Pos, p.currentToken.Type)
-		p.errors = append(p.errors, msg)
+		p.errorf(p.currentToken.Pos, "expected ']', got %s instead", p.currentToken.Type)
 		return nil
 	}
 	p.nextToken()
@@ -222,9 +255,10 @@ func (p *Parser) parseIndex(left Expression) Expression {
 }
 
 func (p *Parser) parseDescent() Expression {
+	token := p.currentToken
 	p.nextToken()
 	index := &DescentExpression{
-		Token: p.currentToken,
+		Token: token,
 		Right: p.parseExpression(LOWEST),
 	}
 	return index
@@ -255,6 +289,21 @@ func (p *Parser) isCurrentToken(t TokenType) bool {
 func (p *Parser) nextToken() {
 	p.currentToken = p.peekToken
 	p.peekToken = p.lex.NextToken()
+	for p.peekToken.Type == COMMENT {
+		comment := &Comment{
+			Token: p.peekToken,
+		}
+		p.comments = append(p.comments, comment)
+		p.pending = append(p.pending, comment)
+		p.peekToken = p.lex.NextToken()
+	}
+}
+
+func (p *Parser) errorf(pos Position, msg string, args ...interface{}) {
+	p.errors = append(p.errors, &ParseError{
+		Pos: pos,
+		Msg: fmt.Sprintf(msg, args...),
+	})
 }
 
 func (p *Parser) expectPeek(t TokenType) bool {
@@ -262,7 +311,6 @@ func (p *Parser) expectPeek(t TokenType) bool {
 		p.nextToken()
 		return true
 	}
-	msg := fmt.Sprintf("Syntax Error: %v expected token to be %s, got %s instead", p.currentToken.Pos, t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.errorf(p.currentToken.Pos, "expected token to be %s, got %s instead", t, p.peekToken.Type)
 	return false
 }
@@ -0,0 +1,187 @@
+package path
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestParserComments(t *testing.T) {
+	query, err := Parse(`# leading
+aaa.bbb; # trailing
+// doc
+aaa
+	/* inner */
+	.ccc
+# dangling`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ast := query.ast
+	if len(ast.Comments) != 5 {
+		t.Fatalf("expected 5 comments, got %d", len(ast.Comments))
+	}
+	if len(ast.Expressions) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(ast.Expressions))
+	}
+
+	first := ast.Expressions[0].(*ExpressionStatement)
+	if len(first.Doc) != 1 || first.Doc[0].Text() != "leading" {
+		t.Errorf("unexpected doc %v", first.Doc)
+	}
+	if first.Comment == nil || first.Comment.Text() != "trailing" {
+		t.Errorf("unexpected comment %v", first.Comment)
+	}
+
+	second := ast.Expressions[1].(*ExpressionStatement)
+	if len(second.Doc) != 2 || second.Doc[0].Text() != "doc" || second.Doc[1].Text() != "inner" {
+		t.Errorf("unexpected doc %v", second.Doc)
+	}
+	if second.Comment != nil {
+		t.Errorf("unexpected comment %v", second.Comment)
+	}
+}
+
+func TestParserOperatorPositions(t *testing.T) {
+	// Expressions with an operator are positioned at the operator, rather
+	// than at the token that follows it.
+	tests := []struct {
+		input     string
+		match     func(Expression) bool
+		tokenType TokenType
+		line      int
+		column    int
+	}{
+		{
+			input:     `aaa.bbb`,
+			match:     func(e Expression) bool { _, ok := e.(*AccessorExpression); return ok },
+			tokenType: PERIOD,
+			line:      1, column: 4,
+		},
+		{
+			input:     `aaa["bbb"]`,
+			match:     func(e Expression) bool { _, ok := e.(*IndexExpression); return ok },
+			tokenType: LBRACKET,
+			line:      1, column: 4,
+		},
+		{
+			input:     `aaa.["bbb"]`,
+			match:     func(e Expression) bool { _, ok := e.(*AccessExpression); return ok },
+			tokenType: LBRACKET,
+			line:      1, column: 5,
+		},
+		{
+			input:     `aaa..bbb`,
+			match:     func(e Expression) bool { _, ok := e.(*DescentExpression); return ok },
+			tokenType: PERIOD,
+			line:      1, column: 5,
+		},
+		{
+			input:     "aaa\n  .bbb",
+			match:     func(e Expression) bool { _, ok := e.(*AccessorExpression); return ok },
+			tokenType: PERIOD,
+			line:      2, column: 3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			query, err := Parse(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var found Expression
+			Inspect(query.AST(), func(e Expression) bool {
+				if found == nil && e != nil && test.match(e) {
+					found = e
+				}
+				return found == nil
+			})
+			if found == nil {
+				t.Fatal("expected a matching expression")
+			}
+
+			var token Token
+			switch node := found.(type) {
+			case *AccessorExpression:
+				token = node.Token
+			case *IndexExpression:
+				token = node.Token
+			case *AccessExpression:
+				token = node.Token
+			case *DescentExpression:
+				token = node.Token
+			}
+			if token.Type != test.tokenType {
+				t.Errorf("expected token %v, got %v", test.tokenType, token.Type)
+			}
+			if pos := found.Pos(); pos.Line != test.line || pos.Column != test.column {
+				t.Errorf("expected position %d:%d, got %d:%d", test.line, test.column, pos.Line, pos.Column)
+			}
+		})
+	}
+}
+
+func TestParserErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected ParseErrors
+	}{
+		{
+			input: "aaa ==\n  ] ",
+			expected: ParseErrors{
+				{Pos: Position{Offset: 9, Line: 2, Column: 3}, Msg: "invalid character ']' found"},
+			},
+		},
+		{
+			input: `"abc`,
+			expected: ParseErrors{
+				{Pos: Position{Offset: 4, Line: 1, Column: 5}, Msg: "string literal not terminated"},
+			},
+		},
+		{
+			input: "a == ]\nb == ]",
+			expected: ParseErrors{
+				{Pos: Position{Offset: 5, Line: 1, Column: 6}, Msg: "invalid character ']' found"},
+				{Pos: Position{Offset: 12, Line: 2, Column: 6}, Msg: "invalid character ']' found"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			_, err := Parse(test.input)
+			errs, ok := errors.Cause(err).(ParseErrors)
+			if !ok {
+				t.Fatalf("expected ParseErrors, got %T", errors.Cause(err))
+			}
+			if !reflect.DeepEqual(errs, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, errs)
+			}
+		})
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: `aaa`, expected: `aaa`},
+		{input: `app.kubernetes.io/name`, expected: `app\.kubernetes\.io\/name`},
+	}
+	for _, test := range tests {
+		if got := QuoteIdent(test.input); got != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, got)
+		}
+		query, err := Parse(QuoteIdent(test.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ident, ok := query.AST().Expressions[0].(*ExpressionStatement).Expression.(*Identifier)
+		if !ok || ident.Token.Literal != test.input {
+			t.Errorf("expected identifier %q to round trip, got %v", test.input, query.AST().Expressions[0])
+		}
+	}
+}
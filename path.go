@@ -1,6 +1,8 @@
 package path
 
 import (
+	"context"
+
 	"github.com/pkg/errors"
 )
 
@@ -9,6 +11,11 @@ var (
 )
 
 // Path holds all the arguments for a given query.
+//
+// A Path is never modified once it has been created and running a query holds
+// no state between runs, so a Path can be shared and run concurrently by
+// multiple goroutines, as long as the scopes it's run against are also safe
+// for concurrent use.
 type Path struct {
 	ast *QueryExpression
 }
@@ -28,34 +35,157 @@ func Parse(src string) (Path, error) {
 	}, nil
 }
 
+// FromAST creates a path from a given query expression, which can be used to
+// run expressions that have been decoded or built without parsing.
+// Returns an error if the expressions are not valid.
+func FromAST(ast *QueryExpression) (Path, error) {
+	if ast == nil {
+		return Path{}, errors.Errorf("missing query expression")
+	}
+	if err := validate(ast); err != nil {
+		return Path{}, errors.WithStack(err)
+	}
+	return Path{
+		ast: ast,
+	}, nil
+}
+
+// AST returns the parsed expressions of the query.
+// The expressions are shared by every copy of the path, so they should not be
+// modified directly, instead use Clone to take a copy first.
+func (q Path) AST() *QueryExpression {
+	return q.ast
+}
+
 // Run the query over a given scope.
 func (q Path) Run(scope Scope) (Scope, error) {
-	result, err := q.run(q.ast, scope)
+	return q.RunContext(context.Background(), scope, RunOptions{})
+}
+
+// RunOptions defines the limits of running a query. A limit of zero or less
+// means there is no limit.
+type RunOptions struct {
+	// MaxSteps is the maximum number of expressions that can be evaluated.
+	MaxSteps int
+	// MaxDepth is the maximum depth of nested expressions that can be
+	// evaluated.
+	MaxDepth int
+	// MaxResults is the maximum number of scopes that can be gathered
+	// together, either by a query with multiple statements or by a descent.
+	MaxResults int
+	// MaxScopes is the maximum number of scopes that can be created whilst
+	// running a query, which gives an approximation of the memory used.
+	MaxScopes int
+	// Tracer is notified of every expression that is evaluated.
+	Tracer Tracer
+}
+
+// RunContext runs the query over a given scope, stopping if the context is
+// done or if any of the limits of the options are exceeded. If a limit is
+// exceeded, then a LimitExceeded error is returned.
+func (q Path) RunContext(ctx context.Context, scope Scope, options RunOptions) (Scope, error) {
+	r := &runner{
+		ctx:     ctx,
+		options: options,
+	}
+	result, err := r.run(q.ast, scope)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 	return result, nil
 }
 
-func (q Path) run(e Expression, scope Scope) (Scope, error) {
-	// Useful for debugging.
-	//fmt.Printf("%[1]T %[1]v\n", e)
+// runner holds the state of a single run of a query.
+type runner struct {
+	ctx     context.Context
+	options RunOptions
+	steps   int
+	depth   int
+	scopes  int
+}
+
+func (r *runner) run(e Expression, scope Scope) (Scope, error) {
+	if err := r.enter(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer r.leave()
 
+	if tracer := r.options.Tracer; tracer != nil {
+		tracer.Enter(e, scope)
+	}
+	result, err := r.eval(e, scope)
+	if tracer := r.options.Tracer; tracer != nil {
+		tracer.Leave(e, result, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.produce(1); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return result, nil
+}
+
+// enter is called before evaluating an expression.
+func (r *runner) enter() error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	r.steps++
+	if max := r.options.MaxSteps; max > 0 && r.steps > max {
+		return &LimitExceeded{Limit: "steps", Max: max}
+	}
+	r.depth++
+	if max := r.options.MaxDepth; max > 0 && r.depth > max {
+		return &LimitExceeded{Limit: "depth", Max: max}
+	}
+	return nil
+}
+
+// leave is called after evaluating an expression.
+func (r *runner) leave() {
+	r.depth--
+}
+
+// produce is called when a number of scopes have been created.
+func (r *runner) produce(n int) error {
+	r.scopes += n
+	if max := r.options.MaxScopes; max > 0 && r.scopes > max {
+		return &LimitExceeded{Limit: "scopes", Max: max}
+	}
+	return nil
+}
+
+// gather is called when adding a scope to a set of results.
+func (r *runner) gather(scopes []Scope) error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	if max := r.options.MaxResults; max > 0 && len(scopes) > max {
+		return &LimitExceeded{Limit: "results", Max: max}
+	}
+	return r.produce(1)
+}
+
+func (r *runner) eval(e Expression, scope Scope) (Scope, error) {
 	switch node := e.(type) {
 	case *QueryExpression:
 		var scopes []Scope
 		for _, exp := range node.Expressions {
-			result, err := q.run(exp, scope)
+			result, err := r.run(exp, scope)
 			if err != nil {
 				return nil, errors.WithStack(err)
 			}
 			scopes = append(scopes, result)
+			if err := r.gather(scopes); err != nil {
+				return nil, errors.WithStack(err)
+			}
 		}
 
 		return NewScopes(scopes), nil
 
 	case *ExpressionStatement:
-		return q.run(node.Expression, scope)
+		return r.run(node.Expression, scope)
 
 	case *Identifier:
 		return scope.GetIdentValue(node.Token.Literal)
@@ -67,22 +197,22 @@ func (q Path) run(e Expression, scope Scope) (Scope, error) {
 		return MakeStringScope(node.Token.Literal), nil
 
 	case *AccessorExpression:
-		parent, err := q.run(node.Left, scope)
+		parent, err := r.run(node.Left, scope)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
-		return q.run(node.Right, parent)
+		return r.run(node.Right, parent)
 
 	case *IndexExpression:
-		left, err := q.run(node.Left, scope)
+		left, err := r.run(node.Left, scope)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
 
-		return q.run(node.Index, left)
+		return r.run(node.Index, left)
 
 	case *AccessExpression:
-		return q.run(node.Index, scope)
+		return r.run(node.Index, scope)
 
 	case *DescentExpression:
 		var scopes []Scope
@@ -93,22 +223,28 @@ func (q Path) run(e Expression, scope Scope) (Scope, error) {
 				return nil, errors.WithStack(err)
 			}
 			scopes = append(scopes, scope)
+			if err := r.gather(scopes); err != nil {
+				return nil, errors.WithStack(err)
+			}
 		}
 		return NewScopes(scopes), nil
 
 	case *InfixExpression:
-		left, err := q.run(node.Left, scope)
-		notFound := errors.Cause(err) == ErrNotFound
-		if err != nil && !notFound {
-			return nil, errors.WithStack(err)
+		left, leftErr := r.run(node.Left, scope)
+		notFound := errors.Cause(leftErr) == ErrNotFound
+		if leftErr != nil && !notFound {
+			return nil, errors.WithStack(leftErr)
 		}
 
-		var right Scope
+		var (
+			right Scope
+			err   error
+		)
 		switch node.Token.Type {
 		case CONDAND, CONDOR:
 			// Don't compute the right handside for a logical operator.
 		default:
-			right, err = q.run(node.Right, scope)
+			right, err = r.run(node.Right, scope)
 			if err != nil {
 				return nil, errors.WithStack(err)
 			}
@@ -116,24 +252,27 @@ func (q Path) run(e Expression, scope Scope) (Scope, error) {
 
 		switch node.Token.Type {
 		case EQ, NEQ, LT, LE, GT, GE:
+			if notFound {
+				return nil, errors.WithStack(leftErr)
+			}
 			op, err := liftOperation(node.Token.Type)
 			if err != nil {
 				return nil, errors.WithStack(err)
 			}
-			return left.RunOperation(op, right)
+			return left.RunOperation(op, unlocated(right))
 		}
 
 		if node.Token.Type == CONDAND {
 			if notFound {
-				return nil, errors.WithStack(err)
+				return nil, errors.WithStack(leftErr)
 			}
 		} else if node.Token.Type == CONDOR {
-			if err == nil {
+			if leftErr == nil {
 				return left, nil
 			}
 		}
 
-		right, err = q.run(node.Right, scope)
+		right, err = r.run(node.Right, scope)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
@@ -8,30 +8,14 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
 )
 
 func TestSuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	bad := NewMockScope(ctrl)
-
-	subsubchild := NewMockScope(ctrl)
-	subsubchild.EXPECT().GetIdentValue("eee").Return(bad, nil).AnyTimes()
-
-	subchild := NewMockScope(ctrl)
-	subchild.EXPECT().GetIdentValue("ccc").Return(bad, nil).AnyTimes()
-	subchild.EXPECT().GetIdentValue("ddd").Return(subsubchild, nil).AnyTimes()
-	subchild.EXPECT().RunOperation(gomock.Any(), gomock.Any()).Return(subchild, nil).AnyTimes()
-
-	child := NewMockScope(ctrl)
-	child.EXPECT().GetIdentValue("bbb").Return(subchild, nil).AnyTimes()
-	child.EXPECT().GetIdentValue("xxx").Return(nil, ErrNotFound).AnyTimes()
-	child.EXPECT().GetIdentValue("yyy").Return(bad, nil).AnyTimes()
-	child.EXPECT().GetAllIdents().Return([]string{"bbb"}).AnyTimes()
-
-	root := NewMockScope(ctrl)
-	root.EXPECT().GetIdentValue("aaa").Return(child, nil).AnyTimes()
+	root := newSuccessScope(ctrl)
 
 	res, err := ioutil.ReadFile("./testfiles/success")
 	if err != nil {
@@ -59,3 +43,68 @@ func TestSuccess(t *testing.T) {
 		})
 	}
 }
+
+// TestComparisonLeftNotFound checks that a comparison whose left hand side
+// isn't found returns the not found error, rather than running the operation
+// on a nil scope.
+func TestComparisonLeftNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	root := newSuccessScope(ctrl)
+
+	tests := []string{
+		`aaa.(xxx == "bbb")`,
+		`aaa.(xxx != bbb)`,
+		`aaa.(xxx < bbb)`,
+		`aaa.(xxx >= bbb)`,
+		`aaa.xxx == aaa.bbb`,
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			query, err := Parse(test)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = query.Run(root)
+			if errors.Cause(err) != ErrNotFound {
+				t.Errorf("expected not found, got %v", err)
+			}
+		})
+	}
+
+	query, err := Parse(`aaa.((xxx == bbb) || bbb)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := query.Run(root); err != nil {
+		t.Errorf("expected the conditional to fall through, got %v", err)
+	}
+}
+
+// newSuccessScope creates the root scope for running the queries found in
+// testfiles/success.
+func newSuccessScope(ctrl *gomock.Controller) Scope {
+	bad := NewMockScope(ctrl)
+
+	subsubchild := NewMockScope(ctrl)
+	subsubchild.EXPECT().GetIdentValue("eee").Return(bad, nil).AnyTimes()
+
+	subchild := NewMockScope(ctrl)
+	subchild.EXPECT().GetIdentValue("ccc").Return(bad, nil).AnyTimes()
+	subchild.EXPECT().GetIdentValue("ddd").Return(subsubchild, nil).AnyTimes()
+	subchild.EXPECT().RunOperation(gomock.Any(), gomock.Any()).Return(subchild, nil).AnyTimes()
+
+	child := NewMockScope(ctrl)
+	child.EXPECT().GetIdentValue("bbb").Return(subchild, nil).AnyTimes()
+	child.EXPECT().GetIdentValue("xxx").Return(nil, ErrNotFound).AnyTimes()
+	child.EXPECT().GetIdentValue("yyy").Return(bad, nil).AnyTimes()
+	child.EXPECT().GetIdentValue("a").Return(nil, ErrNotFound).AnyTimes()
+	child.EXPECT().GetIdentValue("b").Return(nil, ErrNotFound).AnyTimes()
+	child.EXPECT().GetAllIdents().Return([]string{"bbb"}).AnyTimes()
+
+	root := NewMockScope(ctrl)
+	root.EXPECT().GetIdentValue("aaa").Return(child, nil).AnyTimes()
+	return root
+}
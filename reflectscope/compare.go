@@ -0,0 +1,166 @@
+package reflectscope
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+)
+
+// timeLayouts are the layouts a literal is parsed with when it's compared
+// with a time.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// literal returns the text of a scope a value is compared with. Queries only
+// have string literals, so the text is converted to the type of the value.
+func literal(scope path.Scope) (string, error) {
+	switch t := scope.(type) {
+	case path.StringScope:
+		return t.Value(), nil
+	case Value:
+		return t.text()
+	}
+	return "", errors.Errorf("invalid scope comparision")
+}
+
+// text returns the value as a literal.
+func (s Value) text() (string, error) {
+	v := s.v
+	switch v.Kind() {
+	case reflect.Invalid:
+		return "null", nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	case reflect.Struct:
+		if t, ok := s.time(); ok {
+			return t.Format(time.RFC3339Nano), nil
+		}
+	}
+	return "", errors.Errorf("invalid scope comparision")
+}
+
+// compare returns -1, 0 or 1 if the value is less than, equal to or greater
+// than the literal.
+func (s Value) compare(other string) (int, error) {
+	v := s.v
+	switch v.Kind() {
+	case reflect.Invalid:
+		if other == "null" {
+			return 0, nil
+		}
+		return 1, nil
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(other)
+		if err != nil {
+			return 0, errors.Errorf("invalid bool %q", other)
+		}
+		if v.Bool() == b {
+			return 0, nil
+		}
+		return 1, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, err := strconv.ParseInt(other, 10, 64); err == nil {
+			return compareInts(v.Int(), i), nil
+		}
+		return compareFloat(float64(v.Int()), other)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, err := strconv.ParseUint(other, 10, 64); err == nil {
+			switch {
+			case v.Uint() < i:
+				return -1, nil
+			case v.Uint() > i:
+				return 1, nil
+			}
+			return 0, nil
+		}
+		return compareFloat(float64(v.Uint()), other)
+
+	case reflect.Float32, reflect.Float64:
+		return compareFloat(v.Float(), other)
+
+	case reflect.Struct:
+		t, ok := s.time()
+		if !ok {
+			break
+		}
+		o, err := parseTime(other)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		switch {
+		case t.Before(o):
+			return -1, nil
+		case t.After(o):
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, errors.Errorf("unable to compare %s values", v.Kind())
+}
+
+// orderable returns if the value can be compared using an ordering operator.
+func (s Value) orderable() bool {
+	switch s.v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	_, ok := s.time()
+	return ok
+}
+
+func (s Value) time() (time.Time, bool) {
+	if s.v.Kind() != reflect.Struct || s.v.Type() != timeType || !s.v.CanInterface() {
+		return time.Time{}, false
+	}
+	return s.v.Interface().(time.Time), true
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat(a float64, other string) (int, error) {
+	b, err := strconv.ParseFloat(other, 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid number %q", other)
+	}
+	switch {
+	case a < b:
+		return -1, nil
+	case a > b:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("invalid time %q", s)
+}
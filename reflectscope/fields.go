@@ -0,0 +1,147 @@
+package reflectscope
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// field is an identifier of a struct, which may be promoted from an embedded
+// struct.
+type field struct {
+	name  string
+	index []int
+}
+
+// fieldCache holds the fields of each struct type, as they never change.
+var fieldCache sync.Map
+
+// typeFields returns the identifiers of a struct type, in the order they are
+// declared.
+func typeFields(t reflect.Type) []field {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]field)
+	}
+	fields, _ := fieldCache.LoadOrStore(t, collectFields(t))
+	return fields.([]field)
+}
+
+// collectFields walks the fields of a struct type, breadth first through the
+// embedded structs. A field of an outer struct hides a field of the same name
+// of an embedded struct, along with any later field of the same depth.
+func collectFields(t reflect.Type) []field {
+	type embedded struct {
+		t     reflect.Type
+		index []int
+	}
+
+	var (
+		fields  []field
+		seen    = make(map[string]bool)
+		current []embedded
+		next    = []embedded{{t: t}}
+		visited = make(map[reflect.Type]bool)
+	)
+	for len(next) > 0 {
+		current, next = next, nil
+		var found []field
+		for _, e := range current {
+			if visited[e.t] {
+				continue
+			}
+			visited[e.t] = true
+
+			for i := 0; i < e.t.NumField(); i++ {
+				f := e.t.Field(i)
+				if f.PkgPath != "" {
+					// As with encoding/json, unexported fields are skipped
+					// apart from embedded structs.
+					ft := f.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if !f.Anonymous || ft.Kind() != reflect.Struct {
+						continue
+					}
+				}
+				name, ok := fieldName(f)
+				if !ok {
+					continue
+				}
+				index := make([]int, len(e.index), len(e.index)+1)
+				copy(index, e.index)
+				index = append(index, i)
+
+				if f.Anonymous && name == "" {
+					ft := f.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if ft.Kind() == reflect.Struct && ft != timeType {
+						next = append(next, embedded{t: ft, index: index})
+						continue
+					}
+				}
+				if name == "" {
+					name = f.Name
+				}
+				found = append(found, field{name: name, index: index})
+			}
+		}
+
+		for _, f := range found {
+			if seen[f.name] {
+				continue
+			}
+			seen[f.name] = true
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// fieldName returns the name of a field from the path tag, or the json tag if
+// there is no path tag. Returns false if the field is skipped with "-".
+func fieldName(f reflect.StructField) (string, bool) {
+	tag, ok := f.Tag.Lookup("path")
+	if !ok {
+		tag = f.Tag.Get("json")
+	}
+	if tag == "-" {
+		return "", false
+	}
+	if i := strings.IndexByte(tag, ','); i >= 0 {
+		tag = tag[:i]
+	}
+	return tag, true
+}
+
+// fieldByIndex returns the field of a struct value, following the pointers of
+// embedded structs. Returns false if an embedded pointer is nil.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		if v.Type().Field(x).PkgPath == "" {
+			v = v.Field(x)
+			continue
+		}
+
+		// The fields of an unexported embedded struct are read only through
+		// reflection, so the embedded struct is read through its address
+		// instead, which needs an addressable copy of the struct.
+		if !v.CanAddr() {
+			c := reflect.New(v.Type()).Elem()
+			c.Set(v)
+			v = c
+		}
+		field := v.Field(x)
+		v = reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem()
+	}
+	return v, true
+}
@@ -0,0 +1,223 @@
+// Package reflectscope queries Go values directly, without first converting
+// them into maps.
+//
+// The identifiers of a struct are its exported fields, named by the path tag
+// or the json tag if there is no path tag, with the fields of embedded structs
+// promoted. The identifiers of a map are its keys and the identifiers of a
+// slice or an array are its indexes. Pointers and interfaces are followed to
+// the values they hold.
+//
+//	type Person struct {
+//		Name string    `json:"name"`
+//		Age  int       `json:"age"`
+//		Born time.Time `path:"born"`
+//	}
+//
+//	query, _ := path.Parse(`person.(age >= "18")`)
+//	result, err := query.Run(reflectscope.FromValue(map[string]Person{...}))
+package reflectscope
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Value defines the type for querying a Go value.
+type Value struct {
+	v reflect.Value
+}
+
+// FromValue creates a scope from a Go value. Strings are returned as a
+// path.StringScope, everything else as a Value.
+func FromValue(v interface{}) path.Scope {
+	return fromReflect(reflect.ValueOf(v))
+}
+
+func fromReflect(v reflect.Value) path.Scope {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return Value{}
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.String {
+		return path.MakeStringScope(v.String())
+	}
+	return Value{
+		v: v,
+	}
+}
+
+// Interface returns the underlying Go value, which is nil for a nil pointer
+// or interface.
+func (s Value) Interface() interface{} {
+	if !s.v.IsValid() || !s.v.CanInterface() {
+		return nil
+	}
+	return s.v.Interface()
+}
+
+// GetAllIdents returns all the identifiers for a given scope.
+func (s Value) GetAllIdents() []string {
+	switch s.v.Kind() {
+	case reflect.Struct:
+		if s.v.Type() == timeType {
+			break
+		}
+		fields := typeFields(s.v.Type())
+		result := make([]string, 0, len(fields))
+		for _, f := range fields {
+			if _, ok := fieldByIndex(s.v, f.index); ok {
+				result = append(result, f.name)
+			}
+		}
+		return result
+
+	case reflect.Map:
+		result := make([]string, 0, s.v.Len())
+		for _, key := range s.v.MapKeys() {
+			if name, ok := keyName(key); ok {
+				result = append(result, name)
+			}
+		}
+		sort.Strings(result)
+		return result
+
+	case reflect.Slice, reflect.Array:
+		result := make([]string, s.v.Len())
+		for i := range result {
+			result[i] = strconv.Itoa(i)
+		}
+		return result
+	}
+	return make([]string, 0)
+}
+
+// GetIdentValue returns the value of the identifier in a given scope.
+func (s Value) GetIdentValue(v string) (path.Scope, error) {
+	switch s.v.Kind() {
+	case reflect.Struct:
+		if s.v.Type() == timeType {
+			break
+		}
+		for _, f := range typeFields(s.v.Type()) {
+			if f.name != v {
+				continue
+			}
+			if value, ok := fieldByIndex(s.v, f.index); ok {
+				return fromReflect(value), nil
+			}
+		}
+
+	case reflect.Map:
+		if key, ok := s.mapKey(v); ok {
+			if value := s.v.MapIndex(key); value.IsValid() {
+				return fromReflect(value), nil
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		if i, err := strconv.Atoi(v); err == nil && i >= 0 && i < s.v.Len() {
+			return fromReflect(s.v.Index(i)), nil
+		}
+	}
+	return nil, path.NotFoundErrorf("no ident value %q found in scope", v)
+}
+
+// mapKey returns the key of a map for an identifier. Returns false if there
+// is no key for the identifier.
+func (s Value) mapKey(v string) (reflect.Value, bool) {
+	t := s.v.Type().Key()
+	if t.Kind() == reflect.String {
+		return reflect.ValueOf(v).Convert(t), true
+	}
+	for _, key := range s.v.MapKeys() {
+		if name, ok := keyName(key); ok && name == v {
+			return key, true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// keyName returns the identifier of a map key. Only keys that are strings,
+// integers or bools have an identifier.
+func keyName(key reflect.Value) (string, bool) {
+	switch key.Kind() {
+	case reflect.String:
+		return key.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), true
+	case reflect.Bool:
+		return strconv.FormatBool(key.Bool()), true
+	}
+	return "", false
+}
+
+// RunOperation attempts to run an operation on a given scope. Maps and slices
+// return the values that match, everything else is compared with the scope.
+func (s Value) RunOperation(op path.Operation, scope path.Scope) (path.Scope, error) {
+	switch s.v.Kind() {
+	case reflect.Map:
+		result := reflect.MakeMap(s.v.Type())
+		iter := s.v.MapRange()
+		for iter.Next() {
+			if _, err := scope.RunOperation(op, fromReflect(iter.Value())); err == nil {
+				result.SetMapIndex(iter.Key(), iter.Value())
+			}
+		}
+		return Value{v: result}, nil
+
+	case reflect.Slice, reflect.Array:
+		result := reflect.MakeSlice(reflect.SliceOf(s.v.Type().Elem()), 0, 0)
+		for i := 0; i < s.v.Len(); i++ {
+			if _, err := scope.RunOperation(op, fromReflect(s.v.Index(i))); err == nil {
+				result = reflect.Append(result, s.v.Index(i))
+			}
+		}
+		return Value{v: result}, nil
+	}
+
+	other, err := literal(scope)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	cmp, err := s.compare(other)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var match bool
+	switch op {
+	case path.OpEQ:
+		match = cmp == 0
+	case path.OpNEQ:
+		match = cmp != 0
+	default:
+		if !s.orderable() {
+			return nil, errors.Errorf("unable to order %s values", s.v.Kind())
+		}
+		switch op {
+		case path.OpLT:
+			match = cmp < 0
+		case path.OpLE:
+			match = cmp <= 0
+		case path.OpGT:
+			match = cmp > 0
+		case path.OpGE:
+			match = cmp >= 0
+		}
+	}
+	if !match {
+		return nil, path.NotFoundErrorf("no match")
+	}
+	return s, nil
+}
@@ -0,0 +1,305 @@
+package reflectscope
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+)
+
+type Address struct {
+	Street string `json:"street"`
+	City   string `json:"city,omitempty"`
+}
+
+type Audit struct {
+	Created time.Time `json:"created"`
+	Version int       `json:"version"`
+}
+
+type Person struct {
+	*Audit
+	Address
+
+	Name     string            `json:"name"`
+	Nickname string            `path:"nick" json:"nickname"`
+	Age      int               `json:"age"`
+	Height   float64           `json:"height"`
+	Admin    bool              `json:"admin"`
+	Score    uint8             `json:"score"`
+	Manager  *Person           `json:"manager"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]string `json:"labels"`
+	Born     time.Time         `json:"born"`
+	Secret   string            `json:"-"`
+	Version  string            `json:"version"`
+	Untagged string
+	private  string
+}
+
+type hidden struct {
+	Y    string    `json:"y"`
+	When time.Time `json:"when"`
+	z    string
+}
+
+type Outer struct {
+	hidden
+	X string `json:"x"`
+}
+
+func testPerson() Person {
+	return Person{
+		Audit: &Audit{
+			Created: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+			Version: 3,
+		},
+		Address: Address{
+			Street: "1 main street",
+			City:   "london",
+		},
+		Name:     "fred",
+		Nickname: "freddie",
+		Age:      42,
+		Height:   1.8,
+		Admin:    true,
+		Score:    7,
+		Manager:  &Person{Name: "alice"},
+		Tags:     []string{"a", "b"},
+		Labels:   map[string]string{"team": "core"},
+		Born:     time.Date(1980, 5, 6, 0, 0, 0, 0, time.UTC),
+		Secret:   "x",
+		Version:  "v2",
+		Untagged: "u",
+		private:  "p",
+	}
+}
+
+func TestGetAllIdents(t *testing.T) {
+	idents := FromValue(testPerson()).GetAllIdents()
+	expected := []string{
+		"name", "nick", "age", "height", "admin", "score", "manager", "tags",
+		"labels", "born", "version", "Untagged", "created", "street", "city",
+	}
+	if !reflect.DeepEqual(idents, expected) {
+		t.Errorf("expected %v, got %v", expected, idents)
+	}
+
+	// A nil embedded pointer hides its fields.
+	idents = FromValue(&Person{}).GetAllIdents()
+	for _, ident := range idents {
+		if ident == "created" {
+			t.Errorf("expected no fields of a nil embedded struct, got %v", idents)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	root := map[string]interface{}{
+		"person": testPerson(),
+		"ids":    map[int]string{1: "one"},
+		"nil":    (*Person)(nil),
+	}
+	tests := []struct {
+		query    string
+		expected interface{}
+	}{
+		{query: `person.name`, expected: "fred"},
+		{query: `person.nick`, expected: "freddie"},
+		{query: `person.Untagged`, expected: "u"},
+		{query: `person.street`, expected: "1 main street"},
+		{query: `person.version`, expected: "v2"},
+		{query: `person.created`, expected: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{query: `person.manager.name`, expected: "alice"},
+		{query: `person.tags["1"]`, expected: "b"},
+		{query: `person.labels.team`, expected: "core"},
+		{query: `ids["1"]`, expected: "one"},
+		{query: `person.(name == "fred")`, expected: "fred"},
+		{query: `person.(age == "42")`, expected: 42},
+		{query: `person.(age > "41.5")`, expected: 42},
+		{query: `person.(age <= "42")`, expected: 42},
+		{query: `person.(height < "2")`, expected: 1.8},
+		{query: `person.(score >= "7")`, expected: uint8(7)},
+		{query: `person.(admin == "true")`, expected: true},
+		{query: `person.(admin != "false")`, expected: true},
+		{query: `person.(born < "1990-01-01")`, expected: time.Date(1980, 5, 6, 0, 0, 0, 0, time.UTC)},
+		{query: `person.(born == "1980-05-06T00:00:00Z")`, expected: time.Date(1980, 5, 6, 0, 0, 0, 0, time.UTC)},
+		{query: `(nil == "null")`, expected: nil},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := path.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := query.First(FromValue(root))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var value interface{}
+			switch s := result.(type) {
+			case path.StringScope:
+				value = s.Value()
+			case Value:
+				value = s.Interface()
+			}
+			if !reflect.DeepEqual(value, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, value)
+			}
+		})
+	}
+}
+
+func TestRunNoMatch(t *testing.T) {
+	tests := []string{
+		`person.Secret`,
+		`person.private`,
+		`person.Name`,
+		`person.(age > "42")`,
+		`person.(admin == "false")`,
+		`person.(born > "1990-01-01")`,
+		`person.(admin < "true")`,
+		`person.(age == "old")`,
+	}
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			query, err := path.Parse(test)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := query.Run(FromValue(map[string]Person{"person": testPerson()})); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestRunMissingMapKeys(t *testing.T) {
+	root := map[string]interface{}{
+		"codes": map[int]string{0: "zero", 7: "seven"},
+		"flags": map[bool]string{false: "off"},
+	}
+	for _, test := range []string{`codes.missing`, `codes.nope`, `flags.bogus`} {
+		t.Run(test, func(t *testing.T) {
+			query, err := path.Parse(test)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := query.Run(FromValue(root)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestRunUnexportedEmbedded(t *testing.T) {
+	when := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	root := map[string]Outer{
+		"outer": {hidden: hidden{Y: "y", When: when, z: "z"}, X: "x"},
+	}
+	tests := []struct {
+		query    string
+		expected interface{}
+	}{
+		{query: `outer.y`, expected: "y"},
+		{query: `outer.when`, expected: when},
+		{query: `outer.(when < "2021-01-01")`, expected: when},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := path.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := query.First(FromValue(root))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var value interface{}
+			switch s := result.(type) {
+			case path.StringScope:
+				value = s.Value()
+			case Value:
+				value = s.Interface()
+			}
+			if !reflect.DeepEqual(value, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, value)
+			}
+		})
+	}
+
+	idents := FromValue(root["outer"]).GetAllIdents()
+	if expected := []string{"x", "y", "when"}; !reflect.DeepEqual(idents, expected) {
+		t.Errorf("expected %v, got %v", expected, idents)
+	}
+}
+
+// A value that isn't found is ErrNotFound, so that || falls through to the
+// next operand, whereas any other error isn't.
+func TestRunNotFound(t *testing.T) {
+	for _, test := range []struct {
+		query    string
+		notFound bool
+	}{
+		{query: `person.Secret`, notFound: true},
+		{query: `person.labels.missing`, notFound: true},
+		{query: `person.(age > "42")`, notFound: true},
+		{query: `person.(admin == "false")`, notFound: true},
+		{query: `person.(admin < "true")`},
+		{query: `person.(age == "old")`},
+	} {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := path.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = query.Run(FromValue(map[string]Person{"person": testPerson()}))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if notFound := errors.Cause(err) == path.ErrNotFound; notFound != test.notFound {
+				t.Errorf("expected not found to be %t, got %v", test.notFound, err)
+			}
+		})
+	}
+
+	query, err := path.Parse(`person.nickname || person.name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := query.First(FromValue(map[string]Person{"person": testPerson()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := path.MakeStringScope("fred"); result != expected {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
+
+func TestRunOperationFilters(t *testing.T) {
+	ages := FromValue([]int{10, 20, 30})
+	result, err := ages.RunOperation(path.OpEQ, FromValue(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{20}; !reflect.DeepEqual(result.(Value).Interface(), expected) {
+		t.Errorf("expected %v, got %v", expected, result.(Value).Interface())
+	}
+}
+
+func BenchmarkRun(b *testing.B) {
+	scope := FromValue(map[string]Person{"person": testPerson()})
+	query, err := path.Parse(`person.(age > "30")`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := query.Run(scope); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
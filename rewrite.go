@@ -0,0 +1,198 @@
+package path
+
+// ApplyFunc is invoked by Apply for each expression, during traversal.
+// The return value of ApplyFunc controls the traversal, see Apply.
+type ApplyFunc func(*Cursor) bool
+
+// Apply traverses an expression recursively, starting with the root, and
+// calling pre and post for each non-nil expression. Either pre or post may be
+// nil.
+//
+// If pre is not nil, it is called for each expression before the children of
+// the expression are traversed. If pre returns false, then no children are
+// traversed, and post is not called for that expression.
+//
+// If post is not nil, and a prior call of pre didn't return false, then post
+// is called for each expression after its children are traversed. If post
+// returns false, then the traversal is terminated and Apply returns
+// immediately.
+//
+// Only the fields that refer to expressions are traversed; comments are not.
+// If the root is replaced, then the new root is returned, otherwise the
+// (possibly modified) root is returned. The expressions are modified in
+// place, so use Clone if the original must not be modified.
+func Apply(root Expression, pre, post ApplyFunc) (result Expression) {
+	parent := &ExpressionStatement{
+		Expression: root,
+	}
+	defer func() {
+		if r := recover(); r != nil && r != errAbort {
+			panic(r)
+		}
+		result = parent.Expression
+	}()
+
+	a := &application{
+		pre:  pre,
+		post: post,
+	}
+	a.apply(parent, "Expression", nil, nil, func(e Expression) {
+		parent.Expression = e
+	}, root)
+	return
+}
+
+var errAbort = new(int) // singleton, to signal termination of Apply
+
+// Cursor describes an expression encountered during Apply.
+// Information about the expression and its parent is available from the Node,
+// Parent, Name and Index methods.
+type Cursor struct {
+	parent Expression
+	name   string
+	list   *[]Expression
+	iter   *iterator
+	node   Expression
+	set    func(Expression)
+}
+
+// Node returns the current expression.
+func (c *Cursor) Node() Expression { return c.node }
+
+// Parent returns the parent of the current expression.
+func (c *Cursor) Parent() Expression { return c.parent }
+
+// Name returns the name of the parent field that contains the current
+// expression. If the parent is a *QueryExpression then the name is
+// "Expressions" and Index can be used to locate the expression.
+func (c *Cursor) Name() string { return c.name }
+
+// Index reports the index of the current expression in the parent
+// expressions list, or a value < 0 if the current expression is not part of a
+// list.
+func (c *Cursor) Index() int {
+	if c.iter != nil {
+		return c.iter.index
+	}
+	return -1
+}
+
+// Replace replaces the current expression with the new one.
+func (c *Cursor) Replace(e Expression) {
+	c.set(e)
+	c.node = e
+}
+
+// Delete deletes the current expression from its containing list.
+// If the current expression is not part of a list, Delete panics.
+func (c *Cursor) Delete() {
+	if c.list == nil {
+		panic("Delete node not contained in list")
+	}
+	i := c.iter.index
+	list := *c.list
+	*c.list = append(list[:i], list[i+1:]...)
+	c.iter.step--
+}
+
+// InsertAfter inserts a new expression after the current one in its
+// containing list. If the current expression is not part of a list,
+// InsertAfter panics. Apply does not walk the new expression.
+func (c *Cursor) InsertAfter(e Expression) {
+	if c.list == nil {
+		panic("InsertAfter node not contained in list")
+	}
+	c.insert(c.iter.index+1, e)
+	c.iter.step++
+}
+
+// InsertBefore inserts a new expression before the current one in its
+// containing list. If the current expression is not part of a list,
+// InsertBefore panics. Apply does not walk the new expression.
+func (c *Cursor) InsertBefore(e Expression) {
+	if c.list == nil {
+		panic("InsertBefore node not contained in list")
+	}
+	c.insert(c.iter.index, e)
+	c.iter.index++
+}
+
+func (c *Cursor) insert(i int, e Expression) {
+	list := append(*c.list, nil)
+	copy(list[i+1:], list[i:])
+	list[i] = e
+	*c.list = list
+}
+
+type iterator struct {
+	index, step int
+}
+
+type application struct {
+	pre, post ApplyFunc
+	cursor    Cursor
+	iter      iterator
+}
+
+func (a *application) apply(parent Expression, name string, list *[]Expression, iter *iterator, set func(Expression), e Expression) {
+	if e == nil {
+		return
+	}
+
+	saved := a.cursor
+	a.cursor = Cursor{
+		parent: parent,
+		name:   name,
+		list:   list,
+		iter:   iter,
+		node:   e,
+		set:    set,
+	}
+
+	if a.pre != nil && !a.pre(&a.cursor) {
+		a.cursor = saved
+		return
+	}
+
+	switch node := a.cursor.node.(type) {
+	case *QueryExpression:
+		a.applyList(node, "Expressions", &node.Expressions)
+	case *ExpressionStatement:
+		a.apply(node, "Expression", nil, nil, func(e Expression) { node.Expression = e }, node.Expression)
+	case *InfixExpression:
+		a.apply(node, "Left", nil, nil, func(e Expression) { node.Left = e }, node.Left)
+		a.apply(node, "Right", nil, nil, func(e Expression) { node.Right = e }, node.Right)
+	case *AccessorExpression:
+		a.apply(node, "Left", nil, nil, func(e Expression) { node.Left = e }, node.Left)
+		a.apply(node, "Right", nil, nil, func(e Expression) { node.Right = e }, node.Right)
+	case *IndexExpression:
+		a.apply(node, "Left", nil, nil, func(e Expression) { node.Left = e }, node.Left)
+		a.apply(node, "Index", nil, nil, func(e Expression) { node.Index = e }, node.Index)
+	case *AccessExpression:
+		a.apply(node, "Index", nil, nil, func(e Expression) { node.Index = e }, node.Index)
+	case *DescentExpression:
+		a.apply(node, "Right", nil, nil, func(e Expression) { node.Right = e }, node.Right)
+	}
+
+	if a.post != nil && !a.post(&a.cursor) {
+		panic(errAbort)
+	}
+
+	a.cursor = saved
+}
+
+func (a *application) applyList(parent Expression, name string, list *[]Expression) {
+	saved := a.iter
+	iter := &a.iter
+
+	iter.index = 0
+	for iter.index < len(*list) {
+		iter.step = 1
+		a.apply(parent, name, list, iter, func(e Expression) {
+			(*list)[iter.index] = e
+		}, (*list)[iter.index])
+		iter.index += iter.step
+	}
+
+	a.iter = saved
+}
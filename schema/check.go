@@ -0,0 +1,339 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/spoke-d/path"
+)
+
+// Diagnostic is a problem found when checking a query against a schema.
+type Diagnostic struct {
+	// Pos is the position of the expression with the problem.
+	Pos path.Position
+	// Message describes the problem.
+	Message string
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("Type Error:%v %s", d.Pos, d.Message)
+}
+
+// Check the query against the schema of the scope it will be run against,
+// without running the query. The diagnostics are returned in the order they
+// are found in the query.
+//
+// The following problems are reported:
+//
+//   - unknown fields, which the schema doesn't allow.
+//   - comparisons of values with different types, such as a number with a
+//     string.
+//   - impossible filters, which can never match, such as comparing a value
+//     with a string that isn't one of the allowed values.
+//   - optional fields that aren't guarded by a conditional or (||), if
+//     WithOptionalFields is used.
+func Check(q path.Path, s *Schema, options ...CheckOption) []Diagnostic {
+	ast := q.AST()
+	if ast == nil {
+		return nil
+	}
+	return CheckExpression(ast, s, options...)
+}
+
+// CheckOption configures how a query is checked.
+type CheckOption func(*checker)
+
+// WithOptionalFields reports the optional fields of a query that aren't the
+// left hand side of a conditional or (||), as the query fails if they're not
+// found. Every field that a JSON Schema doesn't require is optional, which is
+// why it's not enabled by default.
+func WithOptionalFields() CheckOption {
+	return func(c *checker) {
+		c.optionalFields = true
+	}
+}
+
+// CheckExpression checks an expression against the schema of the scope it
+// will be run against, see Check.
+func CheckExpression(e path.Expression, s *Schema, options ...CheckOption) []Diagnostic {
+	c := &checker{}
+	for _, option := range options {
+		option(c)
+	}
+	c.check(e, s)
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		return c.diagnostics[i].Pos.Offset < c.diagnostics[j].Pos.Offset
+	})
+	return c.diagnostics
+}
+
+// TypeOf returns the schema of the result of the expression, when run against
+// a scope described by the schema. Returns nil if the schema of the result
+// isn't known.
+func TypeOf(e path.Expression, s *Schema) *Schema {
+	c := &checker{}
+	return c.check(e, s)
+}
+
+type checker struct {
+	diagnostics    []Diagnostic
+	optionalFields bool
+	// guarded is the number of conditional ors (||) the expression being
+	// checked is the left hand side of, a missing value falls through to
+	// the right hand side of the conditional.
+	guarded int
+}
+
+func (c *checker) errorf(pos path.Position, msg string, args ...interface{}) {
+	c.diagnostics = append(c.diagnostics, Diagnostic{
+		Pos:     pos,
+		Message: fmt.Sprintf(msg, args...),
+	})
+}
+
+// check returns the schema of the result of the expression, when run against
+// a scope described by the schema. A nil schema means the result is unknown.
+func (c *checker) check(e path.Expression, scope *Schema) *Schema {
+	switch node := e.(type) {
+	case *path.QueryExpression:
+		for _, exp := range node.Expressions {
+			c.check(exp, scope)
+		}
+		return nil
+
+	case *path.ExpressionStatement:
+		return c.check(node.Expression, scope)
+
+	case *path.Identifier:
+		return c.field(node.Pos(), node.Token.Literal, scope)
+
+	case *path.String:
+		name := node.Token.Literal
+		if isAny(scope) {
+			return nil
+		}
+		switch scope.Type {
+		case TypeString:
+			// A string returns itself for every identifier.
+			return scope
+		case TypeObject:
+			if field, ok := scope.Fields[name]; ok {
+				c.optional(node.Pos(), name, field)
+				return field.Schema
+			}
+			if scope.Values != nil {
+				// The string might be a key of the map, otherwise the
+				// string is used as is.
+				return nil
+			}
+		case TypeList:
+			if _, err := strconv.Atoi(name); err == nil {
+				return scope.Items
+			}
+		}
+		return literal(name)
+
+	case *path.AccessorExpression:
+		left := c.check(node.Left, scope)
+		return c.check(node.Right, left)
+
+	case *path.IndexExpression:
+		left := c.check(node.Left, scope)
+		return c.check(node.Index, left)
+
+	case *path.AccessExpression:
+		return c.check(node.Index, scope)
+
+	case *path.DescentExpression:
+		if isAny(scope) {
+			return nil
+		}
+		if scope.Type == TypeList && scope.Items == nil {
+			return nil
+		}
+		return union(scope.Children())
+
+	case *path.InfixExpression:
+		if node.Token.Type == path.CONDOR {
+			c.guarded++
+		}
+		left := c.check(node.Left, scope)
+		if node.Token.Type == path.CONDOR {
+			c.guarded--
+		}
+		right := c.check(node.Right, scope)
+		switch node.Token.Type {
+		case path.CONDAND, path.CONDOR:
+			return union([]*Schema{left, right})
+		}
+		c.compare(node, left, right)
+		return left
+	}
+	return nil
+}
+
+// field returns the schema of the field of a scope, reporting an error if
+// the field can't be found.
+func (c *checker) field(pos path.Position, name string, scope *Schema) *Schema {
+	if isAny(scope) {
+		return nil
+	}
+	switch scope.Type {
+	case TypeObject:
+		if s, ok := scope.Field(name); ok {
+			if field, ok := scope.Fields[name]; ok {
+				c.optional(pos, name, field)
+			}
+			return s
+		}
+		c.errorf(pos, "unknown field %q", name)
+	case TypeList:
+		if _, err := strconv.Atoi(name); err == nil {
+			return scope.Items
+		}
+		c.errorf(pos, "unknown field %q of list, expected an index", name)
+	case TypeString:
+		// A string returns itself for every identifier.
+		return scope
+	default:
+		c.errorf(pos, "unable to access field %q of %s", name, scope.Type)
+	}
+	return nil
+}
+
+// optional reports an optional field that isn't guarded by a conditional or.
+func (c *checker) optional(pos path.Position, name string, field *Field) {
+	if c.optionalFields && field.Optional && c.guarded == 0 {
+		c.errorf(pos, "optional field %q might not be found, guard it with ||", name)
+	}
+}
+
+// compare reports comparisons that can never match.
+func (c *checker) compare(node *path.InfixExpression, left, right *Schema) {
+	if isAny(left) || isAny(right) {
+		return
+	}
+
+	switch left.Type {
+	case TypeObject, TypeList:
+		// Comparing an object filters the values it holds, so at least one of
+		// them has to be comparable.
+		children := left.Children()
+		for _, child := range children {
+			if isAny(child) || comparable(node.Token.Type, child, coerce(right, child.Type)) {
+				return
+			}
+		}
+		c.errorf(node.Pos(), "impossible filter, no value of the %s can be compared with %s using %s", left.Type, right.Type, node.Operator)
+		return
+	}
+
+	right = coerce(right, left.Type)
+	if left.Type != right.Type {
+		c.errorf(node.Pos(), "mismatched types %s and %s using %s", left.Type, right.Type, node.Operator)
+		return
+	}
+	if !orderable(node.Token.Type, left) {
+		c.errorf(node.Pos(), "unable to order %s values using %s", left.Type, node.Operator)
+		return
+	}
+	if node.Token.Type == path.EQ && len(left.Enum) > 0 && len(right.Enum) > 0 && !intersects(left.Enum, right.Enum) {
+		c.errorf(node.Pos(), "impossible filter, %s is never equal to %s", describe(left), describe(right))
+	}
+}
+
+// literal returns the schema of a string literal.
+func literal(value string) *Schema {
+	s := String(value)
+	s.literal = true
+	return s
+}
+
+// coerce returns the schema of a string literal as the given type, if the
+// literal is valid for the type. There are only string literals in a query,
+// so scopes convert them to the type of the value they're compared with.
+func coerce(s *Schema, t Type) *Schema {
+	if !s.literal || len(s.Enum) != 1 {
+		return s
+	}
+	value := fmt.Sprint(s.Enum[0])
+	switch t {
+	case TypeNumber:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return &Schema{Type: TypeNumber, Enum: []interface{}{f}}
+		}
+	case TypeBool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return &Schema{Type: TypeBool, Enum: []interface{}{b}}
+		}
+	case TypeNull:
+		if value == "null" {
+			return &Schema{Type: TypeNull}
+		}
+	}
+	return s
+}
+
+// comparable returns if a comparison of the two schemas can ever match.
+func comparable(op path.TokenType, left, right *Schema) bool {
+	if left.Type != right.Type || !orderable(op, left) {
+		return false
+	}
+	if op == path.EQ && len(left.Enum) > 0 && len(right.Enum) > 0 {
+		return intersects(left.Enum, right.Enum)
+	}
+	return true
+}
+
+func orderable(op path.TokenType, s *Schema) bool {
+	switch op {
+	case path.LT, path.LE, path.GT, path.GE:
+		return s.Type == TypeString || s.Type == TypeNumber
+	}
+	return true
+}
+
+func intersects(a, b []interface{}) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if reflect.DeepEqual(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func describe(s *Schema) string {
+	if len(s.Enum) == 1 {
+		return fmt.Sprintf("%q", fmt.Sprint(s.Enum[0]))
+	}
+	return fmt.Sprintf("one of %v", s.Enum)
+}
+
+// union returns the schema that describes all of the schemas, or nil if they
+// can't be described by a single schema.
+func union(schemas []*Schema) *Schema {
+	if len(schemas) == 0 {
+		return nil
+	}
+	first := schemas[0]
+	for _, s := range schemas[1:] {
+		if isAny(first) || isAny(s) {
+			return nil
+		}
+		if s != first && (s.Type != first.Type || s.Type == TypeObject || s.Type == TypeList) {
+			return nil
+		}
+	}
+	if isAny(first) {
+		return nil
+	}
+	if len(schemas) > 1 && len(first.Enum) > 0 {
+		// The values of the enums might differ.
+		return &Schema{Type: first.Type}
+	}
+	return first
+}
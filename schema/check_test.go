@@ -0,0 +1,167 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/spoke-d/path"
+)
+
+func testSchema() *Schema {
+	user := Object(map[string]*Field{
+		"name":   Required(String()),
+		"age":    Optional(Number()),
+		"active": Required(Bool()),
+		"role":   Required(String("admin", "user")),
+		"emails": Optional(List(String())),
+	})
+	return Object(map[string]*Field{
+		"users":   Required(Map(user)),
+		"owner":   Required(user),
+		"version": Required(String()),
+		"tags":    Optional(Map(String())),
+	})
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{query: `owner.name`},
+		{query: `owner["name"]`},
+		{query: `users.alice.name`},
+		{query: `owner.emails["0"]`},
+		{query: `owner.(name == "fred")`},
+		{query: `owner.(age > "30")`},
+		{query: `owner.(active == "true")`},
+		{query: `owner.(role == "admin")`},
+		{query: `owner.(name == "fred" && age > "30")`},
+		{query: `owner.(nickname || name)`, expected: []string{
+			`Type Error:<:1:8> unknown field "nickname"`,
+		}},
+		{query: `owner.nmae`, expected: []string{
+			`Type Error:<:1:7> unknown field "nmae"`,
+		}},
+		{query: `version.major.minor; owner.emails.first`, expected: []string{
+			`Type Error:<:1:35> unknown field "first" of list, expected an index`,
+		}},
+		{query: `owner.age.value`, expected: []string{
+			`Type Error:<:1:11> unable to access field "value" of number`,
+		}},
+		{query: `owner.(age > "thirty")`, expected: []string{
+			`Type Error:<:1:12> mismatched types number and string using >`,
+		}},
+		{query: `owner.(active < "true")`, expected: []string{
+			`Type Error:<:1:15> unable to order bool values using <`,
+		}},
+		{query: `owner.(role == "superuser")`, expected: []string{
+			`Type Error:<:1:13> impossible filter, one of [admin user] is never equal to "superuser"`,
+		}},
+		{query: `owner.(role == "active")`, expected: []string{
+			// The string is the name of a field, so the field is used.
+			`Type Error:<:1:13> mismatched types string and bool using ==`,
+		}},
+		{query: `tags == "release"`},
+		{query: `owner.emails == "x"`},
+		{query: `owner.emails > "x"`},
+		{query: `users..(active == "true")`},
+		{query: `owner == "x"`},
+		{query: `owner.(age == "x")`, expected: []string{
+			`Type Error:<:1:12> mismatched types number and string using ==`,
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := path.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, diagnostic := range Check(query, testSchema()) {
+				got = append(got, diagnostic.Error())
+			}
+			if len(got) != len(test.expected) {
+				t.Fatalf("expected %q, got %q", test.expected, got)
+			}
+			for i := range got {
+				if got[i] != test.expected[i] {
+					t.Errorf("expected %q, got %q", test.expected[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckImpossibleObjectFilter(t *testing.T) {
+	s := Object(map[string]*Field{
+		"counts": Required(Map(Number())),
+	})
+	query, err := path.Parse(`counts == "many"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diagnostics := Check(query, s)
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", diagnostics)
+	}
+	expected := `Type Error:<:1:8> impossible filter, no value of the object can be compared with string using ==`
+	if got := diagnostics[0].Error(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestCheckOptionalFields(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{query: `owner.name`},
+		{query: `owner.age || owner.name`},
+		{query: `owner.(age || name)`},
+		{query: `(owner.age > "30") || owner.name`},
+		{query: `tags.release || version`},
+		{query: `owner.age`, expected: []string{
+			`Type Error:<:1:7> optional field "age" might not be found, guard it with ||`,
+		}},
+		{query: `owner["age"]`, expected: []string{
+			`Type Error:<:1:7> optional field "age" might not be found, guard it with ||`,
+		}},
+		{query: `users.alice.emails["0"]`, expected: []string{
+			`Type Error:<:1:13> optional field "emails" might not be found, guard it with ||`,
+		}},
+		{query: `owner.name || owner.age`, expected: []string{
+			`Type Error:<:1:21> optional field "age" might not be found, guard it with ||`,
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := path.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, diagnostic := range Check(query, testSchema(), WithOptionalFields()) {
+				got = append(got, diagnostic.Error())
+			}
+			if len(got) != len(test.expected) {
+				t.Fatalf("expected %q, got %q", test.expected, got)
+			}
+			for i := range got {
+				if got[i] != test.expected[i] {
+					t.Errorf("expected %q, got %q", test.expected[i], got[i])
+				}
+			}
+		})
+	}
+
+	query, err := path.Parse(`owner.age`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diagnostics := Check(query, testSchema()); len(diagnostics) != 0 {
+		t.Errorf("expected optional fields to be allowed by default, got %v", diagnostics)
+	}
+}
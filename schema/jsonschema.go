@@ -0,0 +1,265 @@
+package schema
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FromJSONSchema creates a schema from a JSON Schema document.
+//
+// Only a subset of draft 2020-12 is supported, which describes the shape of a
+// document: type, properties, required, additionalProperties, items, enum,
+// const, along with $ref to definitions in $defs of the same document. Other
+// keywords are ignored.
+//
+// As with JSON Schema, an object allows any other fields unless
+// additionalProperties is false or a schema, so unknown fields are only
+// reported for objects that set additionalProperties to false.
+func FromJSONSchema(b []byte) (*Schema, error) {
+	var doc interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	l := &loader{
+		root: doc,
+		refs: make(map[string]*Schema),
+	}
+	s, err := l.load(doc)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return s, nil
+}
+
+type loader struct {
+	root interface{}
+	refs map[string]*Schema
+}
+
+func (l *loader) load(v interface{}) (*Schema, error) {
+	switch t := v.(type) {
+	case bool:
+		// Boolean schemas either allow or reject everything, neither of
+		// which says anything about the shape.
+		return Any(), nil
+	case map[string]interface{}:
+		return l.loadObject(t)
+	}
+	return nil, errors.Errorf("unexpected schema %T", v)
+}
+
+func (l *loader) loadObject(m map[string]interface{}) (*Schema, error) {
+	if ref, ok := m["$ref"]; ok {
+		name, ok := ref.(string)
+		if !ok {
+			return nil, errors.Errorf("unexpected $ref %v", ref)
+		}
+		return l.ref(name)
+	}
+
+	t, err := schemaType(m)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	s := &Schema{Type: t}
+
+	if enum, ok := m["enum"]; ok {
+		values, ok := enum.([]interface{})
+		if !ok {
+			return nil, errors.Errorf("unexpected enum %v", enum)
+		}
+		s.Enum = values
+	}
+	if value, ok := m["const"]; ok {
+		s.Enum = []interface{}{value}
+	}
+
+	switch t {
+	case TypeObject:
+		if err := l.loadProperties(s, m); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	case TypeList:
+		if items, ok := m["items"]; ok {
+			if s.Items, err = l.load(items); err != nil {
+				return nil, errors.Wrap(err, "items")
+			}
+		}
+	}
+	return s, nil
+}
+
+func (l *loader) loadProperties(s *Schema, m map[string]interface{}) error {
+	required := make(map[string]bool)
+	if names, ok := m["required"].([]interface{}); ok {
+		for _, name := range names {
+			if name, ok := name.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	if properties, ok := m["properties"].(map[string]interface{}); ok {
+		s.Fields = make(map[string]*Field, len(properties))
+		for _, name := range sortedNames(properties) {
+			property, err := l.load(properties[name])
+			if err != nil {
+				return errors.Wrapf(err, "property %q", name)
+			}
+			s.Fields[name] = &Field{
+				Schema:   property,
+				Optional: !required[name],
+			}
+		}
+	}
+
+	switch additional := m["additionalProperties"].(type) {
+	case nil:
+		s.Values = Any()
+	case bool:
+		if additional {
+			s.Values = Any()
+		}
+	default:
+		values, err := l.load(additional)
+		if err != nil {
+			return errors.Wrap(err, "additionalProperties")
+		}
+		s.Values = values
+	}
+	return nil
+}
+
+// ref returns the schema of a reference to the definitions of the document.
+// The schema is created before it's loaded, so that recursive references
+// refer to the same schema.
+func (l *loader) ref(name string) (*Schema, error) {
+	if s, ok := l.refs[name]; ok {
+		return s, nil
+	}
+	if !strings.HasPrefix(name, "#") {
+		return nil, errors.Errorf("unsupported $ref %q, only references within the document are supported", name)
+	}
+
+	v := l.root
+	if pointer := strings.TrimPrefix(name, "#"); pointer != "" {
+		for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+			token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, errors.Errorf("$ref %q not found", name)
+			}
+			if v, ok = m[token]; !ok {
+				return nil, errors.Errorf("$ref %q not found", name)
+			}
+		}
+	}
+
+	s := &Schema{}
+	l.refs[name] = s
+	res, err := l.load(v)
+	if err != nil {
+		return nil, errors.Wrapf(err, "$ref %q", name)
+	}
+	*s = *res
+	return s, nil
+}
+
+// schemaType returns the type of a schema, using the other keywords if there
+// isn't a type.
+func schemaType(m map[string]interface{}) (Type, error) {
+	switch t := m["type"].(type) {
+	case string:
+		return typeOf(t)
+	case []interface{}:
+		// A value that's allowed to be null is treated as the other type.
+		var types []Type
+		for _, v := range t {
+			name, ok := v.(string)
+			if !ok {
+				return TypeAny, errors.Errorf("unexpected type %v", v)
+			}
+			typ, err := typeOf(name)
+			if err != nil {
+				return TypeAny, errors.WithStack(err)
+			}
+			if typ != TypeNull {
+				types = append(types, typ)
+			}
+		}
+		if len(types) == 1 {
+			return types[0], nil
+		}
+		return TypeAny, nil
+	case nil:
+	default:
+		return TypeAny, errors.Errorf("unexpected type %v", t)
+	}
+
+	if _, ok := m["properties"]; ok {
+		return TypeObject, nil
+	}
+	if _, ok := m["items"]; ok {
+		return TypeList, nil
+	}
+	if value, ok := m["const"]; ok {
+		return valueType(value), nil
+	}
+	if enum, ok := m["enum"].([]interface{}); ok && len(enum) > 0 {
+		t := valueType(enum[0])
+		for _, value := range enum[1:] {
+			if valueType(value) != t {
+				return TypeAny, nil
+			}
+		}
+		return t, nil
+	}
+	return TypeAny, nil
+}
+
+// valueType returns the type of a decoded JSON value.
+func valueType(v interface{}) Type {
+	switch v.(type) {
+	case map[string]interface{}:
+		return TypeObject
+	case []interface{}:
+		return TypeList
+	case string:
+		return TypeString
+	case float64:
+		return TypeNumber
+	case bool:
+		return TypeBool
+	}
+	return TypeNull
+}
+
+func typeOf(name string) (Type, error) {
+	switch name {
+	case "object":
+		return TypeObject, nil
+	case "array":
+		return TypeList, nil
+	case "string":
+		return TypeString, nil
+	case "number", "integer":
+		return TypeNumber, nil
+	case "boolean":
+		return TypeBool, nil
+	case "null":
+		return TypeNull, nil
+	}
+	return TypeAny, errors.Errorf("unexpected type %q", name)
+}
+
+func sortedNames(m map[string]interface{}) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
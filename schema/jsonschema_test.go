@@ -0,0 +1,141 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spoke-d/path"
+)
+
+const testJSONSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"properties": {
+		"owner": {"$ref": "#/$defs/user"},
+		"users": {
+			"type": "object",
+			"additionalProperties": {"$ref": "#/$defs/user"}
+		},
+		"version": {"type": "string"},
+		"labels": {"type": "object"}
+	},
+	"required": ["owner", "version"],
+	"additionalProperties": false,
+	"$defs": {
+		"user": {
+			"type": "object",
+			"properties": {
+				"name": {"type": "string"},
+				"age": {"type": ["integer", "null"]},
+				"role": {"enum": ["admin", "user"]},
+				"emails": {"type": "array", "items": {"type": "string"}},
+				"manager": {"$ref": "#/$defs/user"}
+			},
+			"required": ["name"],
+			"additionalProperties": false
+		}
+	}
+}`
+
+func TestFromJSONSchema(t *testing.T) {
+	s, err := FromJSONSchema([]byte(testJSONSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Type != TypeObject {
+		t.Fatalf("expected object, got %v", s.Type)
+	}
+	if got, expected := s.FieldNames(), []string{"labels", "owner", "users", "version"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if s.Fields["owner"].Optional || !s.Fields["labels"].Optional {
+		t.Error("expected owner to be required and labels to be optional")
+	}
+	if s.Values != nil {
+		t.Error("expected no additional fields")
+	}
+	if labels := s.Fields["labels"].Schema; labels.Values == nil || labels.Values.Type != TypeAny {
+		t.Error("expected labels to allow any fields")
+	}
+
+	user := s.Fields["owner"].Schema
+	if s.Fields["users"].Schema.Values != user {
+		t.Error("expected references to share the same schema")
+	}
+	if user.Fields["manager"].Schema != user {
+		t.Error("expected recursive reference")
+	}
+	if got := user.Fields["age"].Schema.Type; got != TypeNumber {
+		t.Errorf("expected number, got %v", got)
+	}
+	role := user.Fields["role"].Schema
+	if role.Type != TypeString || !reflect.DeepEqual(role.Enum, []interface{}{"admin", "user"}) {
+		t.Errorf("unexpected role %+v", role)
+	}
+	if emails := user.Fields["emails"].Schema; emails.Type != TypeList || emails.Items.Type != TypeString {
+		t.Errorf("unexpected emails %+v", emails)
+	}
+}
+
+func TestFromJSONSchemaCheck(t *testing.T) {
+	s, err := FromJSONSchema([]byte(testJSONSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query, err := path.Parse(`users.alice.manager.(role == "owner"); owner.nmae`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, diagnostic := range Check(query, s) {
+		got = append(got, diagnostic.Error())
+	}
+	expected := []string{
+		`Type Error:<:1:27> impossible filter, one of [admin user] is never equal to "owner"`,
+		`Type Error:<:1:46> unknown field "nmae"`,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestFromJSONSchemaOpenObject(t *testing.T) {
+	s, err := FromJSONSchema([]byte(`{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Values == nil || s.Values.Type != TypeAny {
+		t.Fatal("expected an object without additionalProperties to allow any fields")
+	}
+
+	query, err := path.Parse(`name; nickname`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diagnostics := Check(query, s); len(diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diagnostics)
+	}
+}
+
+func TestFromJSONSchemaErrors(t *testing.T) {
+	for _, src := range []string{
+		`[]`,
+		`{"type": "date"}`,
+		`{"type": 1}`,
+		`{"$ref": "https://example.com/schema.json"}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"properties": {"name": {"type": "unknown"}}}`,
+		`{`,
+	} {
+		if _, err := FromJSONSchema([]byte(src)); err == nil {
+			t.Errorf("expected error for %s", src)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Package schema describes the shape of the documents queries are run
+// against, which allows queries to be checked before they are run.
+package schema
+
+import "sort"
+
+// Type is the type of a value described by a schema.
+type Type int
+
+// The types of values a schema can describe.
+const (
+	TypeAny Type = iota
+	TypeObject
+	TypeList
+	TypeString
+	TypeNumber
+	TypeBool
+	TypeNull
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeObject:
+		return "object"
+	case TypeList:
+		return "list"
+	case TypeString:
+		return "string"
+	case TypeNumber:
+		return "number"
+	case TypeBool:
+		return "bool"
+	case TypeNull:
+		return "null"
+	}
+	return "any"
+}
+
+// Schema describes the values found at a location of a document.
+type Schema struct {
+	// Type is the type of the value.
+	Type Type
+	// Fields are the known fields of an object.
+	Fields map[string]*Field
+	// Values describes the fields of an object that aren't known, such as the
+	// values of a map. If it's nil, then an object only has the known fields.
+	Values *Schema
+	// Items describes the items of a list.
+	Items *Schema
+	// Enum holds the only values that are allowed, if it's not empty.
+	Enum []interface{}
+
+	// literal is true for the schema of a string literal in a query.
+	literal bool
+}
+
+// Field is a field of an object.
+type Field struct {
+	// Schema describes the value of the field.
+	Schema *Schema
+	// Optional fields might not be found in the object.
+	Optional bool
+}
+
+// Any creates a schema that allows any value.
+func Any() *Schema {
+	return &Schema{Type: TypeAny}
+}
+
+// Object creates a schema for an object with the given fields.
+func Object(fields map[string]*Field) *Schema {
+	return &Schema{
+		Type:   TypeObject,
+		Fields: fields,
+	}
+}
+
+// Map creates a schema for an object where every field has the same schema.
+func Map(values *Schema) *Schema {
+	return &Schema{
+		Type:   TypeObject,
+		Values: values,
+	}
+}
+
+// List creates a schema for a list of items.
+func List(items *Schema) *Schema {
+	return &Schema{
+		Type:  TypeList,
+		Items: items,
+	}
+}
+
+// String creates a schema for a string, which can only be one of the enum
+// values if any are given.
+func String(enum ...string) *Schema {
+	s := &Schema{Type: TypeString}
+	for _, v := range enum {
+		s.Enum = append(s.Enum, v)
+	}
+	return s
+}
+
+// Number creates a schema for a number.
+func Number() *Schema {
+	return &Schema{Type: TypeNumber}
+}
+
+// Bool creates a schema for a boolean.
+func Bool() *Schema {
+	return &Schema{Type: TypeBool}
+}
+
+// Null creates a schema for a null value.
+func Null() *Schema {
+	return &Schema{Type: TypeNull}
+}
+
+// Required creates a field that is always found in an object.
+func Required(s *Schema) *Field {
+	return &Field{
+		Schema: s,
+	}
+}
+
+// Optional creates a field that might not be found in an object.
+func Optional(s *Schema) *Field {
+	return &Field{
+		Schema:   s,
+		Optional: true,
+	}
+}
+
+// Field returns the schema of the field with the given name, or false if the
+// schema doesn't allow the field.
+func (s *Schema) Field(name string) (*Schema, bool) {
+	if s == nil || s.Type == TypeAny {
+		return Any(), true
+	}
+	if s.Type != TypeObject {
+		return nil, false
+	}
+	if field, ok := s.Fields[name]; ok {
+		return field.Schema, true
+	}
+	if s.Values != nil {
+		return s.Values, true
+	}
+	return nil, false
+}
+
+// FieldNames returns the names of the known fields of an object in order.
+func (s *Schema) FieldNames() []string {
+	if s == nil {
+		return nil
+	}
+	names := make([]string, 0, len(s.Fields))
+	for name := range s.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Children returns the schemas of every value that can be found directly
+// within a value.
+func (s *Schema) Children() []*Schema {
+	if s == nil {
+		return nil
+	}
+	switch s.Type {
+	case TypeObject:
+		var children []*Schema
+		for _, name := range s.FieldNames() {
+			children = append(children, s.Fields[name].Schema)
+		}
+		if s.Values != nil {
+			children = append(children, s.Values)
+		}
+		return children
+	case TypeList:
+		if s.Items != nil {
+			return []*Schema{s.Items}
+		}
+	}
+	return nil
+}
+
+func isAny(s *Schema) bool {
+	return s == nil || s.Type == TypeAny
+}
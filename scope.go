@@ -44,6 +44,16 @@ type Scope interface {
 	RunOperation(Operation, Scope) (Scope, error)
 }
 
+// MutableScope is a scope that allows the values of its identifiers to be
+// changed.
+type MutableScope interface {
+	Scope
+	// SetIdentValue sets the value of the identifier in a given scope.
+	SetIdentValue(string, Scope) error
+	// DeleteIdent removes the identifier from a given scope.
+	DeleteIdent(string) error
+}
+
 // Scopes holds a list of scopes to walk over.
 type Scopes struct {
 	scopes []Scope
@@ -65,7 +75,7 @@ func (s Scopes) GetIdentValue(v string) (Scope, error) {
 		}
 		return r, nil
 	}
-	return nil, errors.Errorf("No ident value %q found in scope", v)
+	return nil, NotFoundErrorf("No ident value %q found in scope", v)
 }
 
 // GetAllIdents returns all the identifiers for a given scope.
@@ -81,7 +91,7 @@ func (s Scopes) GetAllIdents() []string {
 func (s Scopes) RunOperation(op Operation, other Scope) (Scope, error) {
 	var lastErr error
 	for _, scope := range s.scopes {
-		res, err := scope.RunOperation(op, scope)
+		res, err := scope.RunOperation(op, other)
 		if err != nil {
 			lastErr = err
 			continue
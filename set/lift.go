@@ -1,13 +1,47 @@
 package set
 
-import "github.com/spoke-d/path"
+import (
+	"encoding/json"
 
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+)
+
+// Lift returns the scope of a value, as decoded by encoding/json.
 func Lift(v interface{}) path.Scope {
+	scope, ok := lift(v)
+	if !ok {
+		panic("missing type")
+	}
+	return scope
+}
+
+func lift(v interface{}) (path.Scope, bool) {
 	switch t := v.(type) {
 	case map[string]interface{}:
-		return MakeSet(t)
+		return MakeSet(t), true
+	case []interface{}:
+		return MakeList(t), true
 	case string:
-		return path.MakeStringScope(t)
+		return path.MakeStringScope(t), true
+	case nil, bool, float64, int, int64, json.Number:
+		return MakeValue(t), true
+	}
+	return nil, false
+}
+
+// Unlift returns the underlying value of a scope, which is the reverse of
+// Lift.
+func Unlift(scope path.Scope) (interface{}, error) {
+	switch t := scope.(type) {
+	case Set:
+		return t.m, nil
+	case List:
+		return t.l, nil
+	case Value:
+		return t.v, nil
+	case path.StringScope:
+		return t.Value(), nil
 	}
-	panic("missing type")
+	return nil, errors.Errorf("unexpected scope %T", scope)
 }
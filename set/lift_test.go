@@ -0,0 +1,55 @@
+package set
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/spoke-d/path"
+)
+
+func TestLiftUnlift(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected path.Scope
+	}{
+		{value: map[string]interface{}{"a": "b"}, expected: Set{}},
+		{value: []interface{}{"a"}, expected: List{}},
+		{value: "a", expected: path.StringScope{}},
+		{value: nil, expected: Value{}},
+		{value: true, expected: Value{}},
+		{value: float64(1.5), expected: Value{}},
+		{value: 1, expected: Value{}},
+		{value: int64(1), expected: Value{}},
+		{value: json.Number("1"), expected: Value{}},
+	}
+	for _, test := range tests {
+		scope := Lift(test.value)
+		if reflect.TypeOf(scope) != reflect.TypeOf(test.expected) {
+			t.Errorf("expected %T for %#v, got %T", test.expected, test.value, scope)
+			continue
+		}
+		value, err := Unlift(scope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(value, test.value) {
+			t.Errorf("expected %#v, got %#v", test.value, value)
+		}
+	}
+}
+
+func TestLiftUnknown(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	Lift(struct{}{})
+}
+
+func TestUnliftUnknown(t *testing.T) {
+	if _, err := Unlift(path.NewScopes(nil)); err == nil {
+		t.Error("expected error")
+	}
+}
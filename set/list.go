@@ -0,0 +1,54 @@
+package set
+
+import (
+	"strconv"
+
+	"github.com/spoke-d/path"
+)
+
+// List defines the type for querying a list with in a set. The identifiers of
+// a list are the indexes of its values.
+type List struct {
+	l []interface{}
+}
+
+// MakeList creates a list from a slice of values.
+func MakeList(l []interface{}) List {
+	return List{
+		l: l,
+	}
+}
+
+// GetAllIdents returns all the identifiers for a given scope.
+func (s List) GetAllIdents() []string {
+	result := make([]string, len(s.l))
+	for i := range s.l {
+		result[i] = strconv.Itoa(i)
+	}
+	return result
+}
+
+// GetIdentValue returns the value of the identifier in a given scope.
+func (s List) GetIdentValue(v string) (path.Scope, error) {
+	if i, err := strconv.Atoi(v); err == nil && i >= 0 && i < len(s.l) {
+		if scope, ok := lift(s.l[i]); ok {
+			return scope, nil
+		}
+	}
+	return nil, path.NotFoundErrorf("no ident value %q found in scope", v)
+}
+
+// RunOperation attempts to run an operation on a given scope
+func (s List) RunOperation(op path.Operation, scope path.Scope) (path.Scope, error) {
+	result := make([]interface{}, 0)
+	for _, v := range s.l {
+		other, ok := lift(v)
+		if !ok {
+			continue
+		}
+		if _, err := scope.RunOperation(op, other); err == nil {
+			result = append(result, v)
+		}
+	}
+	return MakeList(result), nil
+}
@@ -0,0 +1,50 @@
+package set
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spoke-d/path"
+)
+
+func TestList(t *testing.T) {
+	list := MakeList([]interface{}{"a", float64(2), map[string]interface{}{"c": "d"}})
+
+	if expected, got := []string{"0", "1", "2"}, list.GetAllIdents(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	value, err := list.GetIdentValue("0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := path.MakeStringScope("a"); value != expected {
+		t.Errorf("expected %v, got %v", expected, value)
+	}
+	value, err = list.GetIdentValue("2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := value.(Set); !ok {
+		t.Errorf("expected a set, got %T", value)
+	}
+	for _, ident := range []string{"3", "-1", "a"} {
+		if _, err := list.GetIdentValue(ident); err == nil {
+			t.Errorf("expected error for %q", ident)
+		}
+	}
+}
+
+func TestListRunOperation(t *testing.T) {
+	list := MakeList([]interface{}{"a", float64(2), "b", float64(3)})
+
+	result, err := list.RunOperation(path.OpGE, path.MakeStringScope("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The operation is run with the literal on the left, so the values that
+	// are less than or equal to the literal match.
+	if expected := []interface{}{"a", "b"}; !reflect.DeepEqual(result.(List).l, expected) {
+		t.Errorf("expected %v, got %v", expected, result.(List).l)
+	}
+}
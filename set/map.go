@@ -28,23 +28,43 @@ func (s Set) GetAllIdents() []string {
 // GetIdentValue returns the value of the identifier in a given scope.
 func (s Set) GetIdentValue(v string) (path.Scope, error) {
 	if i, ok := s.m[v]; ok {
-		switch t := i.(type) {
-		case map[string]interface{}:
-			return MakeSet(t), nil
-		case string:
-			return path.MakeStringScope(t), nil
+		if scope, ok := lift(i); ok {
+			return scope, nil
 		}
 	}
-	return nil, errors.Errorf("no ident value %q found in scope", v)
+	return nil, path.NotFoundErrorf("no ident value %q found in scope", v)
 }
 
 // RunOperation attempts to run an operation on a given scope
 func (s Set) RunOperation(op path.Operation, scope path.Scope) (path.Scope, error) {
 	result := make(map[string]interface{})
 	for k, v := range s.m {
-		if _, err := scope.RunOperation(op, Lift(v)); err == nil {
+		other, ok := lift(v)
+		if !ok {
+			continue
+		}
+		if _, err := scope.RunOperation(op, other); err == nil {
 			result[k] = v
 		}
 	}
 	return MakeSet(result), nil
 }
+
+// SetIdentValue sets the value of the identifier in a given scope.
+func (s Set) SetIdentValue(v string, value path.Scope) error {
+	i, err := Unlift(value)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	s.m[v] = i
+	return nil
+}
+
+// DeleteIdent removes the identifier from a given scope.
+func (s Set) DeleteIdent(v string) error {
+	if _, ok := s.m[v]; !ok {
+		return path.NotFoundErrorf("no ident value %q found in scope", v)
+	}
+	delete(s.m, v)
+	return nil
+}
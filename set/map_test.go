@@ -0,0 +1,46 @@
+package set
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+)
+
+func TestSetNotFound(t *testing.T) {
+	scope := MakeSet(map[string]interface{}{
+		"name":  "fred",
+		"admin": true,
+		"tags":  []interface{}{"a"},
+	})
+	tests := []struct {
+		query    string
+		notFound bool
+	}{
+		{query: `missing`, notFound: true},
+		{query: `tags.missing`, notFound: true},
+		{query: `(admin == "false")`, notFound: true},
+		{query: `(name == "bob")`, notFound: true},
+		{query: `(admin < "true")`},
+		{query: `(admin == "maybe")`},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := path.Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = query.First(scope)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if notFound := errors.Cause(err) == path.ErrNotFound; notFound != test.notFound {
+				t.Errorf("expected not found to be %t, got %v", test.notFound, err)
+			}
+		})
+	}
+
+	if err := scope.DeleteIdent("missing"); errors.Cause(err) != path.ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package set
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spoke-d/path"
+)
+
+// Value defines the type for numbers, bools and null found with in a set.
+// Queries only have string literals, so a literal is converted to the type of
+// the value when they are compared.
+type Value struct {
+	v interface{}
+}
+
+// MakeValue creates a value from a number, bool or nil.
+func MakeValue(v interface{}) Value {
+	return Value{
+		v: v,
+	}
+}
+
+// Value returns the underlying value.
+func (s Value) Value() interface{} {
+	return s.v
+}
+
+// GetAllIdents returns all the identifiers for a given scope.
+func (s Value) GetAllIdents() []string {
+	return make([]string, 0)
+}
+
+// GetIdentValue returns the value of the identifier in a given scope.
+func (s Value) GetIdentValue(v string) (path.Scope, error) {
+	return s, nil
+}
+
+// RunOperation attempts to run an operation on a given scope
+func (s Value) RunOperation(op path.Operation, scope path.Scope) (path.Scope, error) {
+	var other interface{}
+	switch t := scope.(type) {
+	case Value:
+		other = t.v
+	case path.StringScope:
+		other = t.Value()
+	default:
+		return nil, errors.Errorf("invalid scope comparision")
+	}
+
+	var cmp int
+	switch v := s.v.(type) {
+	case nil:
+		if other != nil && other != "null" {
+			cmp = 1
+		}
+	case bool:
+		o, ok := toBool(other)
+		if !ok {
+			return nil, errors.Errorf("invalid scope comparision")
+		}
+		if v != o {
+			cmp = 1
+		}
+	default:
+		n, ok := toFloat(v)
+		if !ok {
+			return nil, errors.Errorf("unexpected value %T", s.v)
+		}
+		o, ok := toFloat(other)
+		if !ok {
+			return nil, errors.Errorf("invalid scope comparision")
+		}
+		if n < o {
+			cmp = -1
+		} else if n > o {
+			cmp = 1
+		}
+	}
+
+	var match bool
+	switch op {
+	case path.OpEQ:
+		match = cmp == 0
+	case path.OpNEQ:
+		match = cmp != 0
+	case path.OpLT, path.OpLE, path.OpGT, path.OpGE:
+		if _, ok := toFloat(s.v); !ok {
+			return nil, errors.Errorf("unable to order %T values", s.v)
+		}
+		switch op {
+		case path.OpLT:
+			match = cmp < 0
+		case path.OpLE:
+			match = cmp <= 0
+		case path.OpGT:
+			match = cmp > 0
+		case path.OpGE:
+			match = cmp >= 0
+		}
+	}
+	if !match {
+		return nil, path.NotFoundErrorf("no match")
+	}
+	return s, nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(t, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toBool(v interface{}) (bool, bool) {
+	switch t := v.(type) {
+	case bool:
+		return t, true
+	case string:
+		b, err := strconv.ParseBool(t)
+		return b, err == nil
+	}
+	return false, false
+}
@@ -0,0 +1,66 @@
+package set
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spoke-d/path"
+)
+
+func TestValueRunOperation(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		op    path.Operation
+		other path.Scope
+		match bool
+	}{
+		{name: "float equal", value: float64(42), op: path.OpEQ, other: path.MakeStringScope("42"), match: true},
+		{name: "int less", value: 41, op: path.OpLT, other: path.MakeStringScope("41.5"), match: true},
+		{name: "int64 greater", value: int64(41), op: path.OpGT, other: path.MakeStringScope("41.5")},
+		{name: "number", value: json.Number("1e3"), op: path.OpEQ, other: path.MakeStringScope("1000"), match: true},
+		{name: "values", value: float64(1), op: path.OpLE, other: MakeValue(json.Number("1")), match: true},
+		{name: "bool", value: true, op: path.OpEQ, other: path.MakeStringScope("true"), match: true},
+		{name: "bool not equal", value: false, op: path.OpNEQ, other: path.MakeStringScope("true"), match: true},
+		{name: "null", value: nil, op: path.OpEQ, other: path.MakeStringScope("null"), match: true},
+		{name: "null not equal", value: nil, op: path.OpEQ, other: path.MakeStringScope("a")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value := MakeValue(test.value)
+			result, err := value.RunOperation(test.op, test.other)
+			if test.match {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result != value {
+					t.Errorf("expected %v, got %v", value, result)
+				}
+			} else if err == nil {
+				t.Error("expected no match")
+			}
+		})
+	}
+}
+
+func TestValueRunOperationErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		op    path.Operation
+		other path.Scope
+	}{
+		{name: "order bools", value: true, op: path.OpLT, other: path.MakeStringScope("true")},
+		{name: "order null", value: nil, op: path.OpGT, other: path.MakeStringScope("null")},
+		{name: "invalid bool", value: true, op: path.OpEQ, other: path.MakeStringScope("yes")},
+		{name: "invalid number", value: float64(1), op: path.OpEQ, other: path.MakeStringScope("one")},
+		{name: "set", value: float64(1), op: path.OpEQ, other: MakeSet(map[string]interface{}{})},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := MakeValue(test.value).RunOperation(test.op, test.other); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
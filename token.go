@@ -11,6 +11,7 @@ const (
 
 	IDENT
 	STRING
+	COMMENT
 
 	EQ     // ==
 	NEQ    // !=
@@ -44,6 +45,8 @@ func (t TokenType) String() string {
 		return "<IDENT>"
 	case STRING:
 		return "<STRING>"
+	case COMMENT:
+		return "<COMMENT>"
 	case ASSIGN:
 		return "="
 	case BANG:
@@ -87,9 +90,9 @@ func (t TokenType) String() string {
 
 // Position holds the location of the token within the query.
 type Position struct {
-	Offset int
-	Line   int
-	Column int
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
 }
 
 func (p Position) String() string {
@@ -98,10 +101,22 @@ func (p Position) String() string {
 
 // Token defines a token found with in a query, along with the position and what
 // type it is.
+// For strings the Literal holds the unquoted value, whilst Raw holds the text
+// as it was found in the query.
 type Token struct {
 	Pos     Position
 	Type    TokenType
 	Literal string
+	Raw     string
+}
+
+// End returns the position immediately after the token.
+func (t Token) End() Position {
+	text := t.Raw
+	if text == "" {
+		text = t.Literal
+	}
+	return endOf(t.Pos, text)
 }
 
 // MakeToken creates a new token value.
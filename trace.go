@@ -0,0 +1,142 @@
+package path
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Tracer is notified of every expression that is evaluated whilst running a
+// query, which can be used to find out why a query doesn't give the expected
+// results.
+type Tracer interface {
+	// Enter is called before the expression is evaluated against the scope.
+	Enter(Expression, Scope)
+	// Leave is called after the expression is evaluated, with the result or
+	// the error of the expression.
+	Leave(Expression, Scope, error)
+}
+
+// TraceNode records the evaluation of a single expression.
+type TraceNode struct {
+	// Expression is the expression that was evaluated.
+	Expression Expression
+	// Input is the scope the expression was evaluated against.
+	Input Scope
+	// Output is the result of the expression.
+	Output Scope
+	// Count is the number of scopes in the result of the expression.
+	Count int
+	// Err is the error of the expression, if any.
+	Err error
+	// Duration is the time taken to evaluate the expression, including the
+	// expressions it contains.
+	Duration time.Duration
+	// Children are the expressions evaluated as part of the expression.
+	Children []*TraceNode
+
+	start time.Time
+}
+
+// Trace is a Tracer that records every expression evaluated as a tree. The
+// same trace can be used to run more than one query, giving a tree for each.
+type Trace struct {
+	// Roots are the top level expressions that were evaluated, in the order
+	// they were evaluated.
+	Roots []*TraceNode
+	stack []*TraceNode
+}
+
+// Enter is called before the expression is evaluated against the scope.
+func (t *Trace) Enter(e Expression, scope Scope) {
+	node := &TraceNode{
+		Expression: e,
+		Input:      scope,
+		start:      time.Now(),
+	}
+	if len(t.stack) == 0 {
+		t.Roots = append(t.Roots, node)
+	} else {
+		parent := t.stack[len(t.stack)-1]
+		parent.Children = append(parent.Children, node)
+	}
+	t.stack = append(t.stack, node)
+}
+
+// Leave is called after the expression is evaluated, with the result or the
+// error of the expression.
+func (t *Trace) Leave(e Expression, result Scope, err error) {
+	if len(t.stack) == 0 {
+		return
+	}
+	node := t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+
+	node.Duration = time.Since(node.start)
+	node.Output = result
+	node.Err = err
+	node.Count = countScopes(result, err)
+}
+
+// String renders the trace as an indented tree, with an expression per line.
+func (t *Trace) String() string {
+	var buf strings.Builder
+	for _, root := range t.Roots {
+		writeTraceNode(&buf, root, 0)
+	}
+	return buf.String()
+}
+
+func writeTraceNode(buf *strings.Builder, node *TraceNode, depth int) {
+	buf.WriteString(strings.Repeat("  ", depth))
+	fmt.Fprintf(buf, "%s %s: ", strings.TrimPrefix(fmt.Sprintf("%T", node.Expression), "*path."), Format(node.Expression))
+
+	switch {
+	case node.Err == nil:
+		result := "results"
+		if node.Count == 1 {
+			result = "result"
+		}
+		fmt.Fprintf(buf, "%d %s", node.Count, result)
+	case hasTraceErr(node.Children):
+		// Only show the error where it happened.
+		buf.WriteString("error")
+	default:
+		fmt.Fprintf(buf, "error: %v", node.Err)
+	}
+	fmt.Fprintf(buf, " (%s)\n", node.Duration)
+
+	for _, child := range node.Children {
+		writeTraceNode(buf, child, depth+1)
+	}
+}
+
+func hasTraceErr(nodes []*TraceNode) bool {
+	for _, node := range nodes {
+		if node.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func countScopes(scope Scope, err error) int {
+	if err != nil || scope == nil {
+		return 0
+	}
+	if scopes, ok := scope.(*Scopes); ok {
+		return len(scopes.scopes)
+	}
+	return 1
+}
+
+// Explain runs the query over a given scope, recording every expression that
+// is evaluated. The trace is returned even if the query returns an error.
+func (q Path) Explain(scope Scope) (*Trace, error) {
+	trace := &Trace{}
+	_, err := q.RunContext(context.Background(), scope, RunOptions{
+		Tracer: trace,
+	})
+	return trace, err
+}
@@ -0,0 +1,114 @@
+package path_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/spoke-d/path"
+)
+
+var durations = regexp.MustCompile(` \([^)]*\)\n`)
+
+func TestExplain(t *testing.T) {
+	query, err := path.Parse(`company.person.name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trace, err := query.Explain(benchmarkScope())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `QueryExpression company.person.name: 1 result
+  ExpressionStatement company.person.name: 1 result
+    AccessorExpression company.person.name: 1 result
+      AccessorExpression company.person: 1 result
+        Identifier company: 1 result
+        Identifier person: 1 result
+      Identifier name: 1 result
+`
+	if got := durations.ReplaceAllString(trace.String(), "\n"); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	if len(trace.Roots) != 1 {
+		t.Fatalf("expected 1 root, got %d", len(trace.Roots))
+	}
+	if input := trace.Roots[0].Input; input == nil {
+		t.Error("expected input scope")
+	}
+	name := trace.Roots[0].Children[0].Children[0].Children[1]
+	if expected := path.MakeStringScope("fred"); name.Output != expected {
+		t.Errorf("expected %v, got %v", expected, name.Output)
+	}
+}
+
+func TestExplainError(t *testing.T) {
+	query, err := path.Parse(`company.missing; company..`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trace, err := query.Explain(benchmarkScope())
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	expected := `QueryExpression company.missing; company..: error
+  ExpressionStatement company.missing: error
+    AccessorExpression company.missing: error
+      Identifier company: 1 result
+      Identifier missing: error: no ident value "missing" found in scope
+`
+	if got := durations.ReplaceAllString(trace.String(), "\n"); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestTraceMultipleQueries(t *testing.T) {
+	trace := &path.Trace{}
+	for _, src := range []string{
+		`company.person.name; company.address.city`,
+		`company.missing || company.person`,
+	} {
+		query, err := path.Parse(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := query.RunContext(context.Background(), benchmarkScope(), path.RunOptions{
+			Tracer: trace,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(trace.Roots) != 2 {
+		t.Fatalf("expected 2 roots, got %d", len(trace.Roots))
+	}
+	expected := `QueryExpression company.person.name; company.address.city: 2 results
+  ExpressionStatement company.person.name: 1 result
+    AccessorExpression company.person.name: 1 result
+      AccessorExpression company.person: 1 result
+        Identifier company: 1 result
+        Identifier person: 1 result
+      Identifier name: 1 result
+  ExpressionStatement company.address.city: 1 result
+    AccessorExpression company.address.city: 1 result
+      AccessorExpression company.address: 1 result
+        Identifier company: 1 result
+        Identifier address: 1 result
+      Identifier city: 1 result
+QueryExpression company.missing || company.person: 1 result
+  ExpressionStatement company.missing || company.person: 1 result
+    InfixExpression company.missing || company.person: 1 result
+      AccessorExpression company.missing: error
+        Identifier company: 1 result
+        Identifier missing: error: no ident value "missing" found in scope
+      AccessorExpression company.person: 1 result
+        Identifier company: 1 result
+        Identifier person: 1 result
+`
+	if got := durations.ReplaceAllString(trace.String(), "\n"); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
@@ -12,6 +12,11 @@ func MakeStringScope(v string) StringScope {
 	}
 }
 
+// Value returns the string held by the scope.
+func (s StringScope) Value() string {
+	return s.v
+}
+
 // GetAllIdents returns all the identifiers for a given scope.
 func (s StringScope) GetAllIdents() []string {
 	return make([]string, 0)
@@ -56,5 +61,5 @@ func (s StringScope) RunOperation(op Operation, scope Scope) (Scope, error) {
 		}
 	}
 
-	return nil, errors.Errorf("no match")
+	return nil, NotFoundErrorf("no match")
 }
@@ -0,0 +1,69 @@
+package path
+
+import (
+	"github.com/pkg/errors"
+)
+
+// validate ensures that an expression, along with all of its children, can
+// be run.
+func validate(e Expression) error {
+	var err error
+	Inspect(e, func(e Expression) bool {
+		if err != nil || e == nil {
+			return false
+		}
+		err = validateExpression(e)
+		return err == nil
+	})
+	return err
+}
+
+func validateExpression(e Expression) error {
+	switch node := e.(type) {
+	case *QueryExpression:
+		for _, exp := range node.Expressions {
+			if exp == nil {
+				return invalidf(Position{}, "missing expression")
+			}
+		}
+	case *ExpressionStatement:
+		if node.Expression == nil {
+			return invalidf(e.Pos(), "missing expression")
+		}
+	case *InfixExpression:
+		if tokenType, ok := operators[node.Operator]; !ok || tokenType != node.Token.Type {
+			return invalidf(e.Pos(), "unexpected operator %q", node.Operator)
+		}
+		if node.Left == nil || node.Right == nil {
+			return invalidf(e.Pos(), "missing operand for %q", node.Operator)
+		}
+	case *AccessorExpression:
+		if node.Left == nil || node.Right == nil {
+			return invalidf(e.Pos(), "missing accessor expression")
+		}
+	case *IndexExpression:
+		if node.Left == nil || node.Index == nil {
+			return invalidf(e.Pos(), "missing index expression")
+		}
+	case *AccessExpression:
+		if node.Index == nil {
+			return invalidf(e.Pos(), "missing index expression")
+		}
+	case *Identifier:
+		if node.Token.Literal == "" {
+			return invalidf(e.Pos(), "empty identifier")
+		}
+	case *Comment:
+		// Comments are only held by the query, never in place of an
+		// expression.
+		return invalidf(e.Pos(), "unexpected comment in place of an expression")
+	case *DescentExpression, *String, *Empty:
+	default:
+		return invalidf(e.Pos(), "unexpected expression %T", e)
+	}
+	return nil
+}
+
+func invalidf(pos Position, msg string, args ...interface{}) error {
+	return errors.Errorf("Invalid Expression:%v "+msg, append([]interface{}{pos}, args...)...)
+}
@@ -0,0 +1,175 @@
+package path
+
+// Visitor defines a type for walking over the expressions of a query.
+// The Visit method is invoked for each expression encountered by Walk. If the
+// result visitor w is not nil, Walk visits each of the children of the
+// expression with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(e Expression) (w Visitor)
+}
+
+// Walk traverses an expression in depth-first order. It starts by calling
+// v.Visit(e), if the visitor returned is not nil, then Walk is invoked
+// recursively with the visitor for each of the non-nil children of the
+// expression, followed by a call of w.Visit(nil).
+// Comments are not visited.
+func Walk(v Visitor, e Expression) {
+	if v = v.Visit(e); v == nil {
+		return
+	}
+
+	for _, child := range children(e) {
+		Walk(v, child)
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Expression) bool
+
+func (f inspector) Visit(e Expression) Visitor {
+	if f(e) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an expression in depth-first order. It starts by calling
+// f(e), if f returns true, Inspect invokes f recursively for each of the
+// non-nil children of the expression, followed by a call of f(nil).
+func Inspect(e Expression, f func(Expression) bool) {
+	Walk(inspector(f), e)
+}
+
+// NodesAt returns the expressions that enclose the offset within the source,
+// starting with the root and ending with the innermost expression. An offset
+// at the end of an expression is enclosed by the expression, so that the
+// expression before a cursor can be found.
+// Returns nil if the offset is outside of the root.
+func NodesAt(root Expression, offset int) []Expression {
+	var path []Expression
+	for e := root; e != nil; {
+		if offset < start(e) || offset > end(e) {
+			break
+		}
+		path = append(path, e)
+
+		var next Expression
+		for _, child := range children(e) {
+			if offset >= start(child) && offset <= end(child) {
+				next = child
+				break
+			}
+		}
+		e = next
+	}
+	return path
+}
+
+// start returns the offset of the first token of the expression, as the
+// position of an expression with an operator is the position of the
+// operator.
+func start(e Expression) int {
+	offset := e.Pos().Offset
+	for _, child := range children(e) {
+		if s := start(child); s < offset {
+			offset = s
+		}
+	}
+	return offset
+}
+
+// end returns the offset after the last token of the expression.
+func end(e Expression) int {
+	offset := e.End().Offset
+	for _, child := range children(e) {
+		if s := end(child); s > offset {
+			offset = s
+		}
+	}
+	return offset
+}
+
+// children returns all the non-nil children of an expression in the order
+// they appear in the source.
+func children(e Expression) []Expression {
+	var res []Expression
+	add := func(exps ...Expression) {
+		for _, e := range exps {
+			if e != nil {
+				res = append(res, e)
+			}
+		}
+	}
+
+	switch node := e.(type) {
+	case *QueryExpression:
+		add(node.Expressions...)
+	case *ExpressionStatement:
+		add(node.Expression)
+	case *InfixExpression:
+		add(node.Left, node.Right)
+	case *AccessorExpression:
+		add(node.Left, node.Right)
+	case *IndexExpression:
+		add(node.Left, node.Index)
+	case *AccessExpression:
+		add(node.Index)
+	case *DescentExpression:
+		add(node.Right)
+	}
+	return res
+}
+
+// Clone returns a deep copy of an expression, so that it can be modified
+// without affecting the original.
+func Clone(e Expression) Expression {
+	switch node := e.(type) {
+	case *QueryExpression:
+		res := &QueryExpression{
+			Expressions: make([]Expression, len(node.Expressions)),
+			Comments:    node.Comments,
+		}
+		for i, e := range node.Expressions {
+			res.Expressions[i] = Clone(e)
+		}
+		return res
+	case *ExpressionStatement:
+		res := *node
+		res.Expression = Clone(node.Expression)
+		return &res
+	case *InfixExpression:
+		res := *node
+		res.Left = Clone(node.Left)
+		res.Right = Clone(node.Right)
+		return &res
+	case *AccessorExpression:
+		res := *node
+		res.Left = Clone(node.Left)
+		res.Right = Clone(node.Right)
+		return &res
+	case *IndexExpression:
+		res := *node
+		res.Left = Clone(node.Left)
+		res.Index = Clone(node.Index)
+		return &res
+	case *AccessExpression:
+		res := *node
+		res.Index = Clone(node.Index)
+		return &res
+	case *DescentExpression:
+		res := *node
+		res.Right = Clone(node.Right)
+		return &res
+	case *Identifier:
+		res := *node
+		return &res
+	case *String:
+		res := *node
+		return &res
+	case *Empty:
+		res := *node
+		return &res
+	}
+	return e
+}
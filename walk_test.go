@@ -0,0 +1,131 @@
+package path
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	query, err := Parse(`aaa.bbb[ccc]; aaa.(bbb == "x" || .ddd)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var idents []string
+	Inspect(query.AST(), func(e Expression) bool {
+		if ident, ok := e.(*Identifier); ok {
+			idents = append(idents, ident.Token.Literal)
+		}
+		return true
+	})
+
+	if expected, got := "aaa bbb ccc aaa bbb ddd", strings.Join(idents, " "); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestWalkSkipsChildren(t *testing.T) {
+	query, err := Parse(`aaa.bbb[ccc]; ddd`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	Inspect(query.AST(), func(e Expression) bool {
+		if e == nil {
+			return false
+		}
+		count++
+		_, ok := e.(*IndexExpression)
+		return !ok
+	})
+
+	// query, 2 statements, index and ddd.
+	if count != 5 {
+		t.Errorf("expected 5 expressions, got %d", count)
+	}
+}
+
+func TestApply(t *testing.T) {
+	query, err := Parse(`aaa.bbb; ccc; aaa.ddd`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ast := Clone(query.AST())
+	result := Apply(ast, func(c *Cursor) bool {
+		switch node := c.Node().(type) {
+		case *ExpressionStatement:
+			if ident, ok := node.Expression.(*Identifier); ok && ident.Token.Literal == "ccc" {
+				c.Delete()
+				return false
+			}
+		case *Identifier:
+			if node.Token.Literal == "aaa" {
+				c.Replace(&Identifier{
+					Token: MakeToken(IDENT, "zzz"),
+				})
+			}
+		}
+		return true
+	}, nil)
+
+	if expected, got := `zzz.bbb; zzz.ddd`, Format(result); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+	// Ensure the original is untouched.
+	if expected, got := `aaa.bbb; ccc; aaa.ddd`, query.Format(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestApplyReplaceRoot(t *testing.T) {
+	query, err := Parse(`aaa`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := Apply(Clone(query.AST()), nil, func(c *Cursor) bool {
+		if _, ok := c.Node().(*QueryExpression); ok {
+			c.Replace(&Identifier{
+				Token: MakeToken(IDENT, "bbb"),
+			})
+		}
+		return true
+	})
+
+	if expected, got := `bbb`, Format(result); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestNodesAt(t *testing.T) {
+	query, err := Parse(`aaa.bbb[ccc] == "x"; ddd..`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		offset   int
+		expected []string
+	}{
+		{offset: 0, expected: []string{"QueryExpression", "ExpressionStatement", "InfixExpression", "IndexExpression", "AccessorExpression", "Identifier"}},
+		{offset: 5, expected: []string{"QueryExpression", "ExpressionStatement", "InfixExpression", "IndexExpression", "AccessorExpression", "Identifier"}},
+		{offset: 9, expected: []string{"QueryExpression", "ExpressionStatement", "InfixExpression", "IndexExpression", "Identifier"}},
+		{offset: 17, expected: []string{"QueryExpression", "ExpressionStatement", "InfixExpression", "String"}},
+		{offset: 22, expected: []string{"QueryExpression", "ExpressionStatement", "AccessorExpression", "Identifier"}},
+		{offset: 25, expected: []string{"QueryExpression", "ExpressionStatement", "AccessorExpression", "DescentExpression"}},
+		{offset: 30},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, node := range NodesAt(query.AST(), test.offset) {
+			got = append(got, strings.TrimPrefix(fmt.Sprintf("%T", node), "*path."))
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("offset %d: expected %v, got %v", test.offset, test.expected, got)
+		}
+	}
+}